@@ -0,0 +1,122 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package dtmcli
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dtm-labs/dtm/dtmcli/dtmimp"
+	"github.com/dtm-labs/dtm/dtmcli/logger"
+)
+
+// MinBarrierRetention is the min retention of barriers.
+// a barrier should be kept until its global trans is finished, or repeated requests and dangled requests will be accepted
+var MinBarrierRetention = time.Hour
+
+// BarrierCleaner deletes the barriers created before Retention in batches.
+// Retention should be longer than the lifetime of the global trans, for example the DataExpire of dtm
+type BarrierCleaner struct {
+	Retention  time.Duration
+	BatchSize  int64
+	Interval   time.Duration
+	cleanBatch func(retention time.Duration, limit int64) (int64, error)
+	stopped    chan struct{}
+	startOnce  sync.Once
+	stopOnce   sync.Once
+}
+
+// NewBarrierCleaner creates a BarrierCleaner for barrier table in mysql/postgres.
+// the barriers are deleted in the order of create_time, which is indexed.
+// a postgres barrier table created before create_time defaults to now() should be upgraded by sqls/dtmcli.barrier.postgres.upgrade.sql,
+// or its barriers are never cleaned
+func NewBarrierCleaner(db *sql.DB) *BarrierCleaner {
+	return newBarrierCleaner(func(retention time.Duration, limit int64) (int64, error) {
+		return sqlCleanBarriers(db, retention, limit)
+	})
+}
+
+func newBarrierCleaner(cleanBatch func(retention time.Duration, limit int64) (int64, error)) *BarrierCleaner {
+	return &BarrierCleaner{
+		Retention:  7 * 24 * time.Hour,
+		BatchSize:  100,
+		Interval:   time.Minute,
+		cleanBatch: cleanBatch,
+		stopped:    make(chan struct{}),
+	}
+}
+
+// CleanOnce deletes at most BatchSize barriers created before Retention
+func (c *BarrierCleaner) CleanOnce() (int64, error) {
+	if c.Retention < MinBarrierRetention {
+		return 0, fmt.Errorf("barrier retention %v should not be less than %v", c.Retention, MinBarrierRetention)
+	}
+	return c.cleanBatch(c.Retention, c.BatchSize)
+}
+
+// Start starts cleaning in a goroutine until Stop is called. the goroutine is started only once
+func (c *BarrierCleaner) Start() {
+	c.startOnce.Do(func() { go c.run() })
+}
+
+func (c *BarrierCleaner) run() {
+	for {
+		select {
+		case <-c.stopped:
+			return
+		default:
+		}
+		n, err := c.CleanOnce()
+		if err != nil {
+			logger.Errorf("barrier clean error: %v", err)
+		}
+		if n < c.BatchSize {
+			select {
+			case <-c.stopped:
+				return
+			case <-time.After(c.Interval):
+			}
+		}
+	}
+}
+
+// Stop stops the cleaning goroutine started by Start. it is safe to call Stop more than once, or before Start
+func (c *BarrierCleaner) Stop() {
+	c.stopOnce.Do(func() { close(c.stopped) })
+}
+
+// sqlCleanBarriers compares create_time with the time of db, because create_time of mysql has no time zone
+func sqlCleanBarriers(db *sql.DB, retention time.Duration, limit int64) (int64, error) {
+	before := map[string]string{
+		dtmimp.DBTypeMysql:    "date_sub(now(), interval ? second)",
+		dtmimp.DBTypePostgres: "now() - interval '1 second' * ?",
+		dtmimp.DBTypeSqlite:   "datetime('now', -? || ' seconds')",
+	}[dtmimp.GetCurrentDBType()]
+	sql := fmt.Sprintf("select id from %s where create_time < %s order by create_time limit ?", dtmimp.BarrierTableName, before)
+	rows, err := db.Query(dtmimp.GetDBSpecial().GetPlaceHoldSQL(sql), int64(retention/time.Second), limit)
+	if err != nil {
+		return 0, err
+	}
+	ids := []interface{}{}
+	for rows.Next() {
+		var id int64
+		err = rows.Scan(&id)
+		if err != nil {
+			break
+		}
+		ids = append(ids, id)
+	}
+	_ = rows.Close()
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+	holders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	return dtmimp.DBExec(db, fmt.Sprintf("delete from %s where id in (%s)", dtmimp.BarrierTableName, holders), ids...)
+}
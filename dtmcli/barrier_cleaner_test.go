@@ -0,0 +1,46 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package dtmcli
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBarrierCleanerStartStop(t *testing.T) {
+	var calls int64
+	newCleaner := func() *BarrierCleaner {
+		atomic.StoreInt64(&calls, 0)
+		c := newBarrierCleaner(func(retention time.Duration, limit int64) (int64, error) {
+			atomic.AddInt64(&calls, 1)
+			return 0, nil
+		})
+		c.Interval = time.Hour
+		return c
+	}
+
+	c := newCleaner()
+	c.Stop() // before Start
+	c.Stop()
+	c.Start()
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, int64(0), atomic.LoadInt64(&calls))
+
+	c = newCleaner()
+	c.Start()
+	c.Start() // only one goroutine is started
+	for atomic.LoadInt64(&calls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	c.Stop()
+	c.Stop()
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, int64(1), atomic.LoadInt64(&calls))
+}
@@ -3,11 +3,14 @@ package dtmcli
 import (
 	"context"
 	"strings"
+	"time"
 
 	"github.com/dtm-labs/dtm/dtmcli/dtmimp"
 	"github.com/dtm-labs/dtm/dtmcli/logger"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // MongoCall sub-trans barrier for mongo. see http://dtm.pub/practice/barrier
@@ -103,3 +106,39 @@ func mongoInsertBarrier(sc context.Context, mc *mongo.Client, transType string,
 	}
 	return 0, err
 }
+
+// NewMongoBarrierCleaner creates a BarrierCleaner for barrier collection in mongo.
+// the create time of a barrier is got from its ObjectID, so no extra index is needed
+// experimental
+func NewMongoBarrierCleaner(mc *mongo.Client) *BarrierCleaner {
+	return newBarrierCleaner(func(retention time.Duration, limit int64) (int64, error) {
+		return mongoCleanBarriers(mc, retention, limit)
+	})
+}
+
+func mongoCleanBarriers(mc *mongo.Client, retention time.Duration, limit int64) (int64, error) {
+	fs := strings.Split(dtmimp.BarrierTableName, ".")
+	barrier := mc.Database(fs[0]).Collection(fs[1])
+	before := primitive.NewObjectIDFromTimestamp(time.Now().Add(-retention))
+	cursor, err := barrier.Find(context.Background(), bson.M{"_id": bson.M{"$lt": before}},
+		options.Find().SetProjection(bson.M{"_id": 1}).SetSort(bson.M{"_id": 1}).SetLimit(limit))
+	if err != nil {
+		return 0, err
+	}
+	docs := []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}{}
+	err = cursor.All(context.Background(), &docs)
+	if err != nil || len(docs) == 0 {
+		return 0, err
+	}
+	ids := []primitive.ObjectID{}
+	for _, d := range docs {
+		ids = append(ids, d.ID)
+	}
+	r, err := barrier.DeleteMany(context.Background(), bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return 0, err
+	}
+	return r.DeletedCount, nil
+}
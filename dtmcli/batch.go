@@ -0,0 +1,51 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package dtmcli
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/dtm-labs/dtm/dtmcli/dtmimp"
+)
+
+// PrepareMsgBatch prepares many msgs in one request to dtm server.
+// errs[i] is the result of msgs[i]. err is not nil if the whole request failed
+func PrepareMsgBatch(server string, msgs []*Msg, queryPrepared string) (errs []error, err error) {
+	for _, msg := range msgs {
+		msg.QueryPrepared = dtmimp.OrString(queryPrepared, msg.QueryPrepared)
+	}
+	return callMsgBatch(server, msgs, "prepareBatch")
+}
+
+// SubmitMsgBatch submits many msgs in one request to dtm server.
+// errs[i] is the result of msgs[i]. err is not nil if the whole request failed
+func SubmitMsgBatch(server string, msgs []*Msg) (errs []error, err error) {
+	for _, msg := range msgs {
+		msg.BuildCustomOptions()
+	}
+	return callMsgBatch(server, msgs, "submitBatch")
+}
+
+func callMsgBatch(server string, msgs []*Msg, operation string) ([]error, error) {
+	res := struct {
+		Results []dtmimp.BatchResult `json:"results"`
+	}{}
+	resp, err := dtmimp.RestyClient.R().SetBody(msgs).SetResult(&res).Post(fmt.Sprintf("%s/%s", server, operation))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK || len(res.Results) != len(msgs) {
+		return nil, errors.New(resp.String())
+	}
+	errs := make([]error, len(msgs))
+	for i := range res.Results {
+		errs[i] = res.Results[i].ToError()
+	}
+	return errs, nil
+}
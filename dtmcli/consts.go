@@ -35,6 +35,8 @@ const (
 	DBTypeMysql = dtmimp.DBTypeMysql
 	// DBTypePostgres const for driver postgres
 	DBTypePostgres = dtmimp.DBTypePostgres
+	// DBTypeSqlite const for driver sqlite
+	DBTypeSqlite = dtmimp.DBTypeSqlite
 )
 
 // MapSuccess HTTP result of SUCCESS
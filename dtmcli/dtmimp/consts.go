@@ -36,6 +36,8 @@ const (
 	DBTypeMysql = "mysql"
 	// DBTypePostgres const for driver postgres
 	DBTypePostgres = "postgres"
+	// DBTypeSqlite const for driver sqlite
+	DBTypeSqlite = "sqlite"
 	// DBTypeRedis const for driver redis
 	DBTypeRedis = "redis"
 	// Jrpc const for json-rpc
@@ -7,6 +7,7 @@
 package dtmimp
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 )
@@ -74,6 +75,25 @@ func init() {
 	dbSpecials[DBTypePostgres] = &postgresDBSpecial{}
 }
 
+type sqliteDBSpecial struct{}
+
+func (*sqliteDBSpecial) GetPlaceHoldSQL(sql string) string {
+	return sql
+}
+
+// GetXaSQL panics, because sqlite does not support xa
+func (*sqliteDBSpecial) GetXaSQL(command string, xid string) string {
+	panic(errors.New("xa is not supported by sqlite"))
+}
+
+func (*sqliteDBSpecial) GetInsertIgnoreTemplate(tableAndValues string, pgConstraint string) string {
+	return fmt.Sprintf("insert or ignore into %s", tableAndValues)
+}
+
+func init() {
+	dbSpecials[DBTypeSqlite] = &sqliteDBSpecial{}
+}
+
 // GetDBSpecial get DBSpecial for currentDBType
 func GetDBSpecial() DBSpecial {
 	return dbSpecials[currentDBType]
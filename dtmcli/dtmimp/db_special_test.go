@@ -28,5 +28,10 @@ func TestDBSpecial(t *testing.T) {
 	assert.Equal(t, "$1 $2", sp.GetPlaceHoldSQL("? ?"))
 	assert.Equal(t, "begin", sp.GetXaSQL("start", "xa1"))
 	assert.Equal(t, "insert into a(f) values(?) on conflict ON CONSTRAINT c do nothing", sp.GetInsertIgnoreTemplate("a(f) values(?)", "c"))
+	SetCurrentDBType(DBTypeSqlite)
+	sp = GetDBSpecial()
+	assert.Equal(t, "? ?", sp.GetPlaceHoldSQL("? ?"))
+	assert.Error(t, CatchP(func() { sp.GetXaSQL("start", "xa1") }))
+	assert.Equal(t, "insert or ignore into a(f) values(?)", sp.GetInsertIgnoreTemplate("a(f) values(?)", "c"))
 	SetCurrentDBType(old)
 }
@@ -6,7 +6,11 @@
 
 package dtmimp
 
-import "database/sql"
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
 
 // DB inteface of dtmcli db
 type DB interface {
@@ -22,3 +26,38 @@ type DBConf struct {
 	User     string `yaml:"User"`
 	Password string `yaml:"Password"`
 }
+
+// BatchResult is the result of one trans in a batch request
+type BatchResult struct {
+	Gid       string `json:"gid"`
+	DtmResult string `json:"dtm_result,omitempty"` // SUCCESS | FAILURE | ONGOING, empty for other errors
+	Message   string `json:"message,omitempty"`
+}
+
+// NewBatchResult converts the error of a trans to BatchResult
+func NewBatchResult(gid string, err error) BatchResult {
+	r := BatchResult{Gid: gid, DtmResult: ResultSuccess}
+	if errors.Is(err, ErrFailure) {
+		r.DtmResult = ResultFailure
+	} else if errors.Is(err, ErrOngoing) {
+		r.DtmResult = ResultOngoing
+	} else if err != nil {
+		r.DtmResult = ""
+	}
+	if err != nil {
+		r.Message = err.Error()
+	}
+	return r
+}
+
+// ToError converts BatchResult back to error
+func (r *BatchResult) ToError() error {
+	if r.DtmResult == ResultSuccess {
+		return nil
+	} else if r.DtmResult == ResultFailure {
+		return fmt.Errorf("%s. %w", r.Message, ErrFailure)
+	} else if r.DtmResult == ResultOngoing {
+		return fmt.Errorf("%s. %w", r.Message, ErrOngoing)
+	}
+	return errors.New(r.Message)
+}
@@ -203,6 +203,7 @@ func GetDsn(conf DBConf) string {
 			conf.User, conf.Password, host, conf.Port, ""),
 		"postgres": fmt.Sprintf("host=%s user=%s password=%s dbname='%s' port=%d sslmode=disable",
 			host, conf.User, conf.Password, "", conf.Port),
+		"sqlite": conf.Host, // the dir of the database files
 	}[driver]
 	PanicIf(dsn == "", fmt.Errorf("unknow driver: %s", driver))
 	return dsn
@@ -259,3 +260,9 @@ func InsertBarrier(tx DB, transType string, gid string, branchID string, op stri
 	sql := GetDBSpecial().GetInsertIgnoreTemplate(BarrierTableName+"(trans_type, gid, branch_id, op, barrier_id, reason) values(?,?,?,?,?,?)", "uniq_barrier")
 	return DBExec(tx, sql, transType, gid, branchID, op, barrierID, reason)
 }
+
+// InsertOutbox insert a msg to outbox table
+func InsertOutbox(tx DB, gid string, protocol string, dtm string, data string) (int64, error) {
+	sql := fmt.Sprintf("insert into %s(gid, protocol, dtm, data) values(?,?,?,?)", OutboxTableName)
+	return DBExec(tx, sql, gid, protocol, dtm, data)
+}
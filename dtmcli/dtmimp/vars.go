@@ -41,6 +41,9 @@ var PassthroughHeaders = []string{}
 // BarrierTableName the table name of barrier table
 var BarrierTableName = "dtm_barrier.barrier"
 
+// OutboxTableName the table name of msg outbox table
+var OutboxTableName = "dtm_barrier.outbox"
+
 func init() {
 	RestyClient.OnBeforeRequest(func(c *resty.Client, r *resty.Request) error {
 		r.URL = MayReplaceLocalhost(r.URL)
@@ -0,0 +1,217 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package dtmcli
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dtm-labs/dtm/dtmcli/dtmimp"
+	"github.com/dtm-labs/dtm/dtmcli/logger"
+)
+
+// outboxMsg is the content of a msg saved in outbox table
+type outboxMsg struct {
+	dtmimp.TransBase
+	BinPayloads [][]byte `json:"bin_payloads,omitempty"`
+}
+
+// OutboxSubmitFunc submits a msg read from outbox table to dtm
+type OutboxSubmitFunc func(tb *dtmimp.TransBase) error
+
+var outboxSubmitters sync.Map
+
+// RegisterOutboxSubmitter registers the submitter of a protocol. http and json-rpc are registered by dtmcli
+func RegisterOutboxSubmitter(protocol string, submitter OutboxSubmitFunc) {
+	outboxSubmitters.Store(protocol, submitter)
+}
+
+func init() {
+	httpSubmit := func(tb *dtmimp.TransBase) error {
+		err := dtmimp.TransCallDtm(tb, tb, "submit")
+		if err != nil && strings.Contains(err.Error(), ResultFailure) {
+			return fmt.Errorf("%s. %w", err.Error(), ErrFailure)
+		}
+		return err
+	}
+	RegisterOutboxSubmitter("http", httpSubmit)
+	RegisterOutboxSubmitter(dtmimp.Jrpc, httpSubmit)
+}
+
+// DoAndSaveOutbox execs busiCall and saves the msg to outbox table in the same local transaction.
+// the msg will be submitted to dtm later by OutboxRelay, so neither prepare nor queryPrepared is needed
+func (s *Msg) DoAndSaveOutbox(db *sql.DB, busiCall BarrierBusiFunc) error {
+	return s.doAndSaveOutbox(db, dtmimp.OrString(s.Protocol, "http"), busiCall)
+}
+
+// DoAndSaveOutboxWithProtocol is the same as DoAndSaveOutbox, the msg will be submitted by the submitter of protocol
+// this method is used by dtmgrpc
+func (s *Msg) DoAndSaveOutboxWithProtocol(db *sql.DB, protocol string, busiCall BarrierBusiFunc) error {
+	return s.doAndSaveOutbox(db, protocol, busiCall)
+}
+
+func (s *Msg) doAndSaveOutbox(db *sql.DB, protocol string, busiCall BarrierBusiFunc) error {
+	bb, err := BarrierFrom(s.TransType, s.Gid, dtmimp.MsgDoBranch0, dtmimp.MsgDoOp)
+	if err == nil {
+		err = bb.CallWithDB(db, func(tx *sql.Tx) error {
+			err := busiCall(tx)
+			if err == nil {
+				err = s.SaveOutbox(tx, protocol)
+			}
+			return err
+		})
+	}
+	return err
+}
+
+// SaveOutbox saves the msg to outbox table using tx
+func (s *Msg) SaveOutbox(tx DB, protocol string) error {
+	s.BuildCustomOptions()
+	data := dtmimp.MustMarshalString(&outboxMsg{TransBase: s.TransBase, BinPayloads: s.BinPayloads})
+	_, err := dtmimp.InsertOutbox(tx, s.Gid, protocol, s.Dtm, data)
+	return err
+}
+
+// OutboxRelay reads msgs from outbox table and submits them to dtm.
+// submit is idempotent in dtm, so more than one relay can run on the same table
+type OutboxRelay struct {
+	DB        *sql.DB
+	BatchSize int64
+	Interval  time.Duration
+	stopped   chan struct{}
+	stopOnce  sync.Once
+	badMu     sync.Mutex
+	badIDs    []int64 // ids of malformed rows, excluded from later selects
+}
+
+// NewOutboxRelay creates a OutboxRelay
+func NewOutboxRelay(db *sql.DB) *OutboxRelay {
+	return &OutboxRelay{
+		DB:        db,
+		BatchSize: 100,
+		Interval:  time.Second,
+		stopped:   make(chan struct{}),
+	}
+}
+
+var errOutboxMalformed = errors.New("malformed outbox row")
+
+type outboxRow struct {
+	id       int64
+	protocol string
+	dtm      string
+	data     string
+}
+
+// RelayOnce submits at most BatchSize msgs in outbox. submitted msgs will be removed from outbox.
+// a malformed row is kept in outbox, to be repaired or deleted manually, and is not selected again by this relay,
+// so that it will not block the msgs after it
+func (r *OutboxRelay) RelayOnce() (int, error) {
+	r.badMu.Lock()
+	args := []interface{}{}
+	where := ""
+	if len(r.badIDs) > 0 {
+		for _, id := range r.badIDs {
+			args = append(args, id)
+		}
+		where = fmt.Sprintf("where id not in (%s) ", strings.TrimSuffix(strings.Repeat("?,", len(r.badIDs)), ","))
+	}
+	r.badMu.Unlock()
+	args = append(args, r.BatchSize)
+	sql := fmt.Sprintf("select id, protocol, dtm, data from %s %sorder by id limit ?", dtmimp.OutboxTableName, where)
+	rows, err := r.DB.Query(dtmimp.GetDBSpecial().GetPlaceHoldSQL(sql), args...)
+	if err != nil {
+		return 0, err
+	}
+	outboxRows := []outboxRow{}
+	for rows.Next() {
+		o := outboxRow{}
+		err = rows.Scan(&o.id, &o.protocol, &o.dtm, &o.data)
+		if err != nil {
+			break
+		}
+		outboxRows = append(outboxRows, o)
+	}
+	_ = rows.Close()
+	if err != nil {
+		return 0, err
+	}
+	relayed := 0
+	var rerr error
+	for _, o := range outboxRows {
+		err := r.relayRow(&o)
+		if errors.Is(err, errOutboxMalformed) {
+			r.badMu.Lock()
+			r.badIDs = append(r.badIDs, o.id)
+			r.badMu.Unlock()
+		}
+		if err == nil {
+			relayed++
+		} else if rerr == nil {
+			rerr = err
+		}
+	}
+	return relayed, rerr
+}
+
+func (r *OutboxRelay) relayRow(o *outboxRow) error {
+	msg := outboxMsg{}
+	if err := json.Unmarshal([]byte(o.data), &msg); err != nil {
+		logger.Errorf("outbox row %d is malformed and skipped: %v data: %s", o.id, err, o.data)
+		return fmt.Errorf("outbox row %d: %v. %w", o.id, err, errOutboxMalformed)
+	}
+	msg.TransBase.BinPayloads = msg.BinPayloads
+	msg.Dtm = o.dtm
+	submitter, ok := outboxSubmitters.Load(o.protocol)
+	if !ok {
+		logger.Errorf("outbox row %d has no submitter for protocol %s and is skipped", o.id, o.protocol)
+		return fmt.Errorf("outbox row %d: no submitter for protocol %s. %w", o.id, o.protocol, errOutboxMalformed)
+	}
+	err := submitter.(OutboxSubmitFunc)(&msg.TransBase)
+	if errors.Is(err, ErrFailure) { // the gid has been aborted or finished in dtm, it will never be submitted
+		logger.Errorf("outbox msg %s submit failed and will be dropped: %v", msg.Gid, err)
+		err = nil
+	}
+	if err == nil {
+		_, err = dtmimp.DBExec(r.DB, fmt.Sprintf("delete from %s where id=?", dtmimp.OutboxTableName), o.id)
+	}
+	return err
+}
+
+// Start starts relaying in a goroutine until Stop is called
+func (r *OutboxRelay) Start() {
+	go func() {
+		for {
+			select {
+			case <-r.stopped:
+				return
+			default:
+			}
+			n, err := r.RelayOnce()
+			if err != nil {
+				logger.Errorf("outbox relay error: %v", err)
+			}
+			if n < int(r.BatchSize) {
+				select {
+				case <-r.stopped:
+					return
+				case <-time.After(r.Interval):
+				}
+			}
+		}
+	}()
+}
+
+// Stop stops the relaying goroutine started by Start. it can be called more than once
+func (r *OutboxRelay) Stop() {
+	r.stopOnce.Do(func() { close(r.stopped) })
+}
@@ -67,6 +67,11 @@ func SetBarrierTableName(tablename string) {
 	dtmimp.BarrierTableName = tablename
 }
 
+// SetOutboxTableName sets msg outbox table name
+func SetOutboxTableName(tablename string) {
+	dtmimp.OutboxTableName = tablename
+}
+
 // GetRestyClient get the resty.Client for http request
 func GetRestyClient() *resty.Client {
 	return dtmimp.RestyClient
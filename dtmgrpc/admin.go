@@ -0,0 +1,45 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package dtmgrpc
+
+import (
+	context "context"
+
+	"github.com/dtm-labs/dtm/dtmgrpc/dtmgimp"
+	"github.com/dtm-labs/dtm/dtmgrpc/dtmgpb"
+)
+
+// QueryTrans queries the global trans and its branches of gid.
+// Transaction of the reply is nil if gid is not found
+func QueryTrans(grpcServer string, gid string) (*dtmgpb.DtmQueryReply, error) {
+	return dtmgimp.MustGetDtmClient(grpcServer).Query(context.Background(), &dtmgpb.DtmQueryRequest{Gid: gid})
+}
+
+// ListTrans lists the global trans page by page. pass "" as the position of the first page,
+// and NextPosition of the reply for the next page. NextPosition is "" if there is no more data
+func ListTrans(grpcServer string, position string, limit int64) (*dtmgpb.DtmListReply, error) {
+	return dtmgimp.MustGetDtmClient(grpcServer).List(context.Background(), &dtmgpb.DtmListRequest{Position: position, Limit: limit})
+}
+
+// ForceStop changes the status of an unfinished global trans to failed, so it will not be processed any more.
+// Use with caution in production environment
+func ForceStop(grpcServer string, gid string) error {
+	_, err := dtmgimp.MustGetDtmClient(grpcServer).ForceStop(context.Background(), &dtmgpb.DtmRequest{Gid: gid})
+	return GrpcError2DtmError(err)
+}
+
+// RetryTrans makes an unfinished global trans be processed as soon as possible, with the retry backoff reset
+func RetryTrans(grpcServer string, gid string) error {
+	_, err := dtmgimp.MustGetDtmClient(grpcServer).Retry(context.Background(), &dtmgpb.DtmRequest{Gid: gid})
+	return GrpcError2DtmError(err)
+}
+
+// ResetCronTime resets the next cron time of the global trans whose next cron time is later than now + timeoutSeconds.
+// 0 for the server defaults: 3 * TimeoutToFail for timeoutSeconds, and 100 for limit
+func ResetCronTime(grpcServer string, timeoutSeconds int64, limit int64) (*dtmgpb.DtmResetCronTimeReply, error) {
+	return dtmgimp.MustGetDtmClient(grpcServer).ResetCronTime(context.Background(), &dtmgpb.DtmResetCronTimeRequest{TimeoutSeconds: timeoutSeconds, Limit: limit})
+}
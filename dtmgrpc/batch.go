@@ -0,0 +1,55 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package dtmgrpc
+
+import (
+	context "context"
+	"fmt"
+
+	"github.com/dtm-labs/dtm/dtmcli/dtmimp"
+	"github.com/dtm-labs/dtm/dtmgrpc/dtmgimp"
+	"github.com/dtm-labs/dtm/dtmgrpc/dtmgpb"
+)
+
+// PrepareMsgGrpcBatch prepares many msgs in one request to dtm server.
+// errs[i] is the result of msgs[i]. err is not nil if the whole request failed
+func PrepareMsgGrpcBatch(server string, msgs []*MsgGrpc, queryPrepared string) (errs []error, err error) {
+	for _, msg := range msgs {
+		msg.QueryPrepared = dtmimp.OrString(queryPrepared, msg.QueryPrepared)
+	}
+	return callMsgGrpcBatch(server, msgs, "PrepareBatch")
+}
+
+// SubmitMsgGrpcBatch submits many msgs in one request to dtm server.
+// errs[i] is the result of msgs[i]. err is not nil if the whole request failed
+func SubmitMsgGrpcBatch(server string, msgs []*MsgGrpc) (errs []error, err error) {
+	for _, msg := range msgs {
+		msg.Msg.BuildCustomOptions()
+	}
+	return callMsgGrpcBatch(server, msgs, "SubmitBatch")
+}
+
+func callMsgGrpcBatch(server string, msgs []*MsgGrpc, operation string) ([]error, error) {
+	req := &dtmgpb.DtmBatchRequest{}
+	for _, msg := range msgs {
+		req.Requests = append(req.Requests, dtmgimp.DtmRequestFromTransBase(&msg.TransBase))
+	}
+	reply := &dtmgpb.DtmBatchReply{}
+	err := dtmgimp.MustGetGrpcConn(server, false).Invoke(context.Background(), "/dtmgimp.Dtm/"+operation, req, reply)
+	if err != nil {
+		return nil, err
+	}
+	if len(reply.Results) != len(msgs) {
+		return nil, fmt.Errorf("%d results returned for %d msgs", len(reply.Results), len(msgs))
+	}
+	errs := make([]error, len(msgs))
+	for i, r := range reply.Results {
+		br := dtmimp.BatchResult{Gid: r.Gid, DtmResult: r.DtmResult, Message: r.Message}
+		errs[i] = br.ToError()
+	}
+	return errs, nil
+}
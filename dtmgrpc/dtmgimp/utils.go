@@ -27,7 +27,12 @@ func MustProtoMarshal(msg proto.Message) []byte {
 // DtmGrpcCall make a convenient call to dtm
 func DtmGrpcCall(s *dtmimp.TransBase, operation string) error {
 	reply := emptypb.Empty{}
-	return MustGetGrpcConn(s.Dtm, false).Invoke(context.Background(), "/dtmgimp.Dtm/"+operation, &dtmgpb.DtmRequest{
+	return MustGetGrpcConn(s.Dtm, false).Invoke(context.Background(), "/dtmgimp.Dtm/"+operation, DtmRequestFromTransBase(s), &reply)
+}
+
+// DtmRequestFromTransBase converts TransBase to the request sent to dtm
+func DtmRequestFromTransBase(s *dtmimp.TransBase) *dtmgpb.DtmRequest {
+	return &dtmgpb.DtmRequest{
 		Gid:       s.Gid,
 		TransType: s.TransType,
 		TransOptions: &dtmgpb.DtmTransOptions{
@@ -42,7 +47,7 @@ func DtmGrpcCall(s *dtmimp.TransBase, operation string) error {
 		CustomedData:  s.CustomData,
 		BinPayloads:   s.BinPayloads,
 		Steps:         dtmimp.MustMarshalString(s.Steps),
-	}, &reply)
+	}
 }
 
 const dtmpre string = "dtm-"
@@ -28,6 +28,14 @@ type DtmClient interface {
 	Prepare(ctx context.Context, in *DtmRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	Abort(ctx context.Context, in *DtmRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	RegisterBranch(ctx context.Context, in *DtmBranchRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	PrepareBatch(ctx context.Context, in *DtmBatchRequest, opts ...grpc.CallOption) (*DtmBatchReply, error)
+	SubmitBatch(ctx context.Context, in *DtmBatchRequest, opts ...grpc.CallOption) (*DtmBatchReply, error)
+	Watch(ctx context.Context, in *DtmWatchRequest, opts ...grpc.CallOption) (Dtm_WatchClient, error)
+	Query(ctx context.Context, in *DtmQueryRequest, opts ...grpc.CallOption) (*DtmQueryReply, error)
+	List(ctx context.Context, in *DtmListRequest, opts ...grpc.CallOption) (*DtmListReply, error)
+	ForceStop(ctx context.Context, in *DtmRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	ResetCronTime(ctx context.Context, in *DtmResetCronTimeRequest, opts ...grpc.CallOption) (*DtmResetCronTimeReply, error)
+	Retry(ctx context.Context, in *DtmRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 }
 
 type dtmClient struct {
@@ -83,6 +91,101 @@ func (c *dtmClient) RegisterBranch(ctx context.Context, in *DtmBranchRequest, op
 	return out, nil
 }
 
+func (c *dtmClient) PrepareBatch(ctx context.Context, in *DtmBatchRequest, opts ...grpc.CallOption) (*DtmBatchReply, error) {
+	out := new(DtmBatchReply)
+	err := c.cc.Invoke(ctx, "/dtmgimp.Dtm/PrepareBatch", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dtmClient) SubmitBatch(ctx context.Context, in *DtmBatchRequest, opts ...grpc.CallOption) (*DtmBatchReply, error) {
+	out := new(DtmBatchReply)
+	err := c.cc.Invoke(ctx, "/dtmgimp.Dtm/SubmitBatch", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dtmClient) Watch(ctx context.Context, in *DtmWatchRequest, opts ...grpc.CallOption) (Dtm_WatchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Dtm_ServiceDesc.Streams[0], "/dtmgimp.Dtm/Watch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &dtmWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Dtm_WatchClient interface {
+	Recv() (*DtmWatchEvent, error)
+	grpc.ClientStream
+}
+
+type dtmWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *dtmWatchClient) Recv() (*DtmWatchEvent, error) {
+	m := new(DtmWatchEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *dtmClient) Query(ctx context.Context, in *DtmQueryRequest, opts ...grpc.CallOption) (*DtmQueryReply, error) {
+	out := new(DtmQueryReply)
+	err := c.cc.Invoke(ctx, "/dtmgimp.Dtm/Query", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dtmClient) List(ctx context.Context, in *DtmListRequest, opts ...grpc.CallOption) (*DtmListReply, error) {
+	out := new(DtmListReply)
+	err := c.cc.Invoke(ctx, "/dtmgimp.Dtm/List", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dtmClient) ForceStop(ctx context.Context, in *DtmRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/dtmgimp.Dtm/ForceStop", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dtmClient) ResetCronTime(ctx context.Context, in *DtmResetCronTimeRequest, opts ...grpc.CallOption) (*DtmResetCronTimeReply, error) {
+	out := new(DtmResetCronTimeReply)
+	err := c.cc.Invoke(ctx, "/dtmgimp.Dtm/ResetCronTime", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dtmClient) Retry(ctx context.Context, in *DtmRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/dtmgimp.Dtm/Retry", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // DtmServer is the server API for Dtm service.
 // All implementations must embed UnimplementedDtmServer
 // for forward compatibility
@@ -92,6 +195,14 @@ type DtmServer interface {
 	Prepare(context.Context, *DtmRequest) (*emptypb.Empty, error)
 	Abort(context.Context, *DtmRequest) (*emptypb.Empty, error)
 	RegisterBranch(context.Context, *DtmBranchRequest) (*emptypb.Empty, error)
+	PrepareBatch(context.Context, *DtmBatchRequest) (*DtmBatchReply, error)
+	SubmitBatch(context.Context, *DtmBatchRequest) (*DtmBatchReply, error)
+	Watch(*DtmWatchRequest, Dtm_WatchServer) error
+	Query(context.Context, *DtmQueryRequest) (*DtmQueryReply, error)
+	List(context.Context, *DtmListRequest) (*DtmListReply, error)
+	ForceStop(context.Context, *DtmRequest) (*emptypb.Empty, error)
+	ResetCronTime(context.Context, *DtmResetCronTimeRequest) (*DtmResetCronTimeReply, error)
+	Retry(context.Context, *DtmRequest) (*emptypb.Empty, error)
 	mustEmbedUnimplementedDtmServer()
 }
 
@@ -114,6 +225,30 @@ func (UnimplementedDtmServer) Abort(context.Context, *DtmRequest) (*emptypb.Empt
 func (UnimplementedDtmServer) RegisterBranch(context.Context, *DtmBranchRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method RegisterBranch not implemented")
 }
+func (UnimplementedDtmServer) PrepareBatch(context.Context, *DtmBatchRequest) (*DtmBatchReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PrepareBatch not implemented")
+}
+func (UnimplementedDtmServer) SubmitBatch(context.Context, *DtmBatchRequest) (*DtmBatchReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SubmitBatch not implemented")
+}
+func (UnimplementedDtmServer) Watch(*DtmWatchRequest, Dtm_WatchServer) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedDtmServer) Query(context.Context, *DtmQueryRequest) (*DtmQueryReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Query not implemented")
+}
+func (UnimplementedDtmServer) List(context.Context, *DtmListRequest) (*DtmListReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method List not implemented")
+}
+func (UnimplementedDtmServer) ForceStop(context.Context, *DtmRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ForceStop not implemented")
+}
+func (UnimplementedDtmServer) ResetCronTime(context.Context, *DtmResetCronTimeRequest) (*DtmResetCronTimeReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResetCronTime not implemented")
+}
+func (UnimplementedDtmServer) Retry(context.Context, *DtmRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Retry not implemented")
+}
 func (UnimplementedDtmServer) mustEmbedUnimplementedDtmServer() {}
 
 // UnsafeDtmServer may be embedded to opt out of forward compatibility for this service.
@@ -217,6 +352,153 @@ func _Dtm_RegisterBranch_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Dtm_PrepareBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DtmBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DtmServer).PrepareBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dtmgimp.Dtm/PrepareBatch",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DtmServer).PrepareBatch(ctx, req.(*DtmBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Dtm_SubmitBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DtmBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DtmServer).SubmitBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dtmgimp.Dtm/SubmitBatch",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DtmServer).SubmitBatch(ctx, req.(*DtmBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Dtm_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DtmWatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DtmServer).Watch(m, &dtmWatchServer{stream})
+}
+
+type Dtm_WatchServer interface {
+	Send(*DtmWatchEvent) error
+	grpc.ServerStream
+}
+
+type dtmWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *dtmWatchServer) Send(m *DtmWatchEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Dtm_Query_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DtmQueryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DtmServer).Query(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dtmgimp.Dtm/Query",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DtmServer).Query(ctx, req.(*DtmQueryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Dtm_List_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DtmListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DtmServer).List(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dtmgimp.Dtm/List",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DtmServer).List(ctx, req.(*DtmListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Dtm_ForceStop_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DtmRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DtmServer).ForceStop(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dtmgimp.Dtm/ForceStop",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DtmServer).ForceStop(ctx, req.(*DtmRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Dtm_ResetCronTime_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DtmResetCronTimeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DtmServer).ResetCronTime(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dtmgimp.Dtm/ResetCronTime",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DtmServer).ResetCronTime(ctx, req.(*DtmResetCronTimeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Dtm_Retry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DtmRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DtmServer).Retry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dtmgimp.Dtm/Retry",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DtmServer).Retry(ctx, req.(*DtmRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Dtm_ServiceDesc is the grpc.ServiceDesc for Dtm service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -244,7 +526,41 @@ var Dtm_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "RegisterBranch",
 			Handler:    _Dtm_RegisterBranch_Handler,
 		},
+		{
+			MethodName: "PrepareBatch",
+			Handler:    _Dtm_PrepareBatch_Handler,
+		},
+		{
+			MethodName: "SubmitBatch",
+			Handler:    _Dtm_SubmitBatch_Handler,
+		},
+		{
+			MethodName: "Query",
+			Handler:    _Dtm_Query_Handler,
+		},
+		{
+			MethodName: "List",
+			Handler:    _Dtm_List_Handler,
+		},
+		{
+			MethodName: "ForceStop",
+			Handler:    _Dtm_ForceStop_Handler,
+		},
+		{
+			MethodName: "ResetCronTime",
+			Handler:    _Dtm_ResetCronTime_Handler,
+		},
+		{
+			MethodName: "Retry",
+			Handler:    _Dtm_Retry_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _Dtm_Watch_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "dtmgrpc/dtmgpb/dtmgimp.proto",
 }
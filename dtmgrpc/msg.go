@@ -21,6 +21,12 @@ type MsgGrpc struct {
 	dtmcli.Msg
 }
 
+func init() {
+	dtmcli.RegisterOutboxSubmitter("grpc", func(tb *dtmimp.TransBase) error {
+		return GrpcError2DtmError(dtmgimp.DtmGrpcCall(tb, "Submit"))
+	})
+}
+
 // NewMsgGrpc create new msg
 func NewMsgGrpc(server string, gid string) *MsgGrpc {
 	return &MsgGrpc{Msg: *dtmcli.NewMsg(server, gid)}
@@ -84,3 +90,9 @@ func (s *MsgGrpc) DoAndSubmit(queryPrepared string, busiCall func(bb *dtmcli.Bra
 	}
 	return err
 }
+
+// DoAndSaveOutbox execs busiCall and saves the msg to outbox table in the same local transaction.
+// the msg will be submitted to dtm later by dtmcli.OutboxRelay
+func (s *MsgGrpc) DoAndSaveOutbox(db *sql.DB, busiCall dtmcli.BarrierBusiFunc) error {
+	return s.Msg.DoAndSaveOutboxWithProtocol(db, "grpc", busiCall)
+}
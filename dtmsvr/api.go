@@ -7,23 +7,32 @@
 package dtmsvr
 
 import (
+	"context"
 	"fmt"
+	"sync"
 
 	"github.com/dtm-labs/dtm/dtmcli"
 	"github.com/dtm-labs/dtm/dtmcli/dtmimp"
 	"github.com/dtm-labs/dtm/dtmcli/logger"
 	"github.com/dtm-labs/dtm/dtmsvr/storage"
+	"github.com/dtm-labs/dtm/dtmutil"
 )
 
 func svcSubmit(t *TransGlobal) interface{} {
 	t.Status = dtmcli.StatusSubmitted
 	branches, err := t.saveNew()
+	return submitSaved(t, branches, err)
+}
 
+func submitSaved(t *TransGlobal, branches []TransBranch, err error) error {
 	if err == storage.ErrUniqueConflict {
-		dbt := GetTransGlobal(t.Gid)
+		dbt := getTransGlobal(t.context(), t.Gid)
+		if err := t.checkFingerprint(dbt); err != nil {
+			return err
+		}
 		if dbt.Status == dtmcli.StatusPrepared {
 			dbt.changeStatus(t.Status)
-			branches = GetStore().FindBranches(t.Gid)
+			branches = dbt.findBranches()
 		} else if dbt.Status != dtmcli.StatusSubmitted {
 			return fmt.Errorf("current status '%s', cannot sumbmit. %w", dbt.Status, dtmcli.ErrFailure)
 		}
@@ -34,8 +43,15 @@ func svcSubmit(t *TransGlobal) interface{} {
 func svcPrepare(t *TransGlobal) interface{} {
 	t.Status = dtmcli.StatusPrepared
 	_, err := t.saveNew()
+	return prepareSaved(t, err)
+}
+
+func prepareSaved(t *TransGlobal, err error) error {
 	if err == storage.ErrUniqueConflict {
-		dbt := GetTransGlobal(t.Gid)
+		dbt := getTransGlobal(t.context(), t.Gid)
+		if err := t.checkFingerprint(dbt); err != nil {
+			return err
+		}
 		if dbt.Status != dtmcli.StatusPrepared {
 			return fmt.Errorf("current status '%s', cannot prepare. %w", dbt.Status, dtmcli.ErrFailure)
 		}
@@ -44,8 +60,62 @@ func svcPrepare(t *TransGlobal) interface{} {
 	return err
 }
 
+// batchWaitWorkers is the max number of the trans with WaitResult in a batch processed concurrently
+const batchWaitWorkers = 16
+
+func svcSubmitBatch(ts []*TransGlobal) []dtmimp.BatchResult {
+	for _, t := range ts {
+		t.Status = dtmcli.StatusSubmitted
+	}
+	branches, errs := saveNewBatch(ts)
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, batchWaitWorkers)
+	for i := range ts {
+		if errs[i] != nil && errs[i] != storage.ErrUniqueConflict {
+			continue
+		}
+		submit := func(i int) {
+			defer handlePanic(&errs[i])
+			errs[i] = submitSaved(ts[i], branches[i], errs[i])
+		}
+		if !ts[i].WaitResult { // processed in background, submitSaved returns immediately
+			submit(i)
+			continue
+		}
+		wg.Add(1)
+		workers <- struct{}{}
+		go func(i int) { // trans with WaitResult will be processed concurrently
+			defer func() { <-workers; wg.Done() }()
+			submit(i)
+		}(i)
+	}
+	wg.Wait()
+	return batchResults(ts, errs)
+}
+
+func svcPrepareBatch(ts []*TransGlobal) []dtmimp.BatchResult {
+	for _, t := range ts {
+		t.Status = dtmcli.StatusPrepared
+	}
+	_, errs := saveNewBatch(ts)
+	for i, t := range ts {
+		errs[i] = dtmimp.CatchP(func() {
+			dtmimp.E2P(prepareSaved(t, errs[i]))
+		})
+	}
+	return batchResults(ts, errs)
+}
+
+func batchResults(ts []*TransGlobal, errs []error) []dtmimp.BatchResult {
+	results := make([]dtmimp.BatchResult, len(ts))
+	for i, t := range ts {
+		results[i] = dtmimp.NewBatchResult(t.Gid, errs[i])
+	}
+	return results
+}
+
 func svcAbort(t *TransGlobal) interface{} {
-	dbt := GetTransGlobal(t.Gid)
+	dbt := getTransGlobal(t.context(), t.Gid)
 	if dbt.TransType == "msg" && dbt.Status == dtmcli.StatusPrepared {
 		dbt.changeStatus(dtmcli.StatusFailed)
 		return nil
@@ -54,12 +124,12 @@ func svcAbort(t *TransGlobal) interface{} {
 		return fmt.Errorf("trans type: '%s' current status '%s', cannot abort. %w", dbt.TransType, dbt.Status, dtmcli.ErrFailure)
 	}
 	dbt.changeStatus(dtmcli.StatusAborting)
-	branches := GetStore().FindBranches(t.Gid)
+	branches := dbt.findBranches()
 	return dbt.Process(branches)
 }
 
 func svcForceStop(t *TransGlobal) interface{} {
-	dbt := GetTransGlobal(t.Gid)
+	dbt := getTransGlobal(t.context(), t.Gid)
 	if dbt.Status == dtmcli.StatusSucceed || dbt.Status == dtmcli.StatusFailed {
 		return fmt.Errorf("global transaction force stop error. status: %s. error: %w", dbt.Status, dtmcli.ErrFailure)
 	}
@@ -67,7 +137,19 @@ func svcForceStop(t *TransGlobal) interface{} {
 	return nil
 }
 
-func svcRegisterBranch(transType string, branch *TransBranch, data map[string]string) error {
+// svcRetry makes an unfinished trans be picked up by cron as soon as possible, with the retry backoff reset
+func svcRetry(t *TransGlobal) interface{} {
+	dbt := getTransGlobal(t.context(), t.Gid)
+	if dbt.Status != dtmcli.StatusSubmitted && dbt.Status != dtmcli.StatusAborting && dbt.Status != dtmcli.StatusPrepared {
+		return fmt.Errorf("global transaction retry error. status: %s. error: %w", dbt.Status, dtmcli.ErrFailure)
+	}
+	e2p(GetStoreV2().TouchCronTimeContext(dbt.context(), &dbt.TransGlobalStore, dbt.getNextCronInterval(cronReset), dtmutil.GetNextTime(0)))
+	scheduleWakeup(&dbt.TransGlobalStore)
+	logger.Infof("Retry for: %s", dbt.TransGlobalStore.String())
+	return nil
+}
+
+func svcRegisterBranch(ctx context.Context, transType string, branch *TransBranch, data map[string]string) error {
 	branches := []TransBranch{*branch, *branch}
 	if transType == "tcc" {
 		for i, b := range []string{dtmimp.OpCancel, dtmimp.OpConfirm} {
@@ -83,9 +165,10 @@ func svcRegisterBranch(transType string, branch *TransBranch, data map[string]st
 		return fmt.Errorf("unknow trans type: %s", transType)
 	}
 
-	err := dtmimp.CatchP(func() {
-		GetStore().LockGlobalSaveBranches(branch.Gid, dtmcli.StatusPrepared, branches, -1)
-	})
+	err := encodeBranches(ctx, branches)
+	if err == nil {
+		err = GetStoreV2().LockGlobalSaveBranchesContext(ctx, branch.Gid, dtmcli.StatusPrepared, branches, -1)
+	}
 	if err == storage.ErrNotFound {
 		msg := fmt.Sprintf("no trans with gid: %s status: %s found", branch.Gid, dtmcli.StatusPrepared)
 		logger.Errorf(msg)
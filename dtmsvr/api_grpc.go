@@ -8,11 +8,18 @@ package dtmsvr
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/dtm-labs/dtm/dtmcli"
+	"github.com/dtm-labs/dtm/dtmcli/dtmimp"
 	"github.com/dtm-labs/dtm/dtmgrpc"
 	pb "github.com/dtm-labs/dtm/dtmgrpc/dtmgpb"
+	"github.com/dtm-labs/dtm/dtmsvr/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // dtmServer is used to implement dtmgimp.DtmServer.
@@ -40,7 +47,7 @@ func (s *dtmServer) Abort(ctx context.Context, in *pb.DtmRequest) (*emptypb.Empt
 }
 
 func (s *dtmServer) RegisterBranch(ctx context.Context, in *pb.DtmBranchRequest) (*emptypb.Empty, error) {
-	r := svcRegisterBranch(in.TransType, &TransBranch{
+	r := svcRegisterBranch(ctx, in.TransType, &TransBranch{
 		Gid:      in.Gid,
 		BranchID: in.BranchID,
 		Status:   dtmcli.StatusPrepared,
@@ -48,3 +55,155 @@ func (s *dtmServer) RegisterBranch(ctx context.Context, in *pb.DtmBranchRequest)
 	}, in.Data)
 	return &emptypb.Empty{}, dtmgrpc.DtmError2GrpcError(r)
 }
+
+func (s *dtmServer) PrepareBatch(ctx context.Context, in *pb.DtmBatchRequest) (*pb.DtmBatchReply, error) {
+	return batchReply(svcPrepareBatch(transesFromDtmBatchRequest(ctx, in))), nil
+}
+
+func (s *dtmServer) SubmitBatch(ctx context.Context, in *pb.DtmBatchRequest) (*pb.DtmBatchReply, error) {
+	return batchReply(svcSubmitBatch(transesFromDtmBatchRequest(ctx, in))), nil
+}
+
+func (s *dtmServer) Watch(in *pb.DtmWatchRequest, stream pb.Dtm_WatchServer) error {
+	err := svcWatch(in.Gid, in.TransType, stream.Context().Done(), func(e *watchEvent) error {
+		return stream.Send(&pb.DtmWatchEvent{Gid: e.Gid, TransType: e.TransType, BranchID: e.BranchID, Op: e.Op, Status: e.Status})
+	})
+	if errors.Is(err, errWatcherDropped) {
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	return err
+}
+
+func (s *dtmServer) Query(ctx context.Context, in *pb.DtmQueryRequest) (*pb.DtmQueryReply, error) {
+	if in.Gid == "" {
+		return nil, status.New(codes.InvalidArgument, "no gid specified").Err()
+	}
+	reply := &pb.DtmQueryReply{}
+	ctx = storage.WithStaleReads(ctx)
+	trans, err := GetStoreV2().FindTransGlobalStoreContext(ctx, in.Gid)
+	if err == nil {
+		reply.Transaction = transGlobalToPb(trans)
+	} else if err != storage.ErrNotFound {
+		return nil, err
+	}
+	branches, err := GetStoreV2().FindBranchesContext(ctx, in.Gid)
+	if err == nil {
+		err = readablePayloads(branches)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i := range branches {
+		reply.Branches = append(reply.Branches, transBranchToPb(&branches[i]))
+	}
+	return reply, nil
+}
+
+func (s *dtmServer) List(ctx context.Context, in *pb.DtmListRequest) (*pb.DtmListReply, error) {
+	position := in.Position
+	limit := in.Limit
+	if limit == 0 {
+		limit = 100
+	}
+	reply := &pb.DtmListReply{}
+	globals, err := GetStoreV2().ScanTransGlobalStoresContext(storage.WithStaleReads(ctx), &position, limit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range globals {
+		reply.Transactions = append(reply.Transactions, transGlobalToPb(&globals[i]))
+	}
+	reply.NextPosition = position
+	return reply, nil
+}
+
+func (s *dtmServer) ForceStop(ctx context.Context, in *pb.DtmRequest) (*emptypb.Empty, error) {
+	r := svcForceStop(TransFromDtmRequest(ctx, in))
+	return &emptypb.Empty{}, dtmgrpc.DtmError2GrpcError(r)
+}
+
+func (s *dtmServer) ResetCronTime(ctx context.Context, in *pb.DtmResetCronTimeRequest) (*pb.DtmResetCronTimeReply, error) {
+	timeout := in.TimeoutSeconds
+	if timeout == 0 {
+		timeout = 3 * conf.TimeoutToFail
+	}
+	limit := in.Limit
+	if limit == 0 {
+		limit = 100
+	}
+	succeedCount, hasRemaining, err := GetStoreV2().ResetCronTimeContext(ctx, time.Duration(timeout)*time.Second, limit)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.DtmResetCronTimeReply{SucceedCount: succeedCount, HasRemaining: hasRemaining}, nil
+}
+
+func (s *dtmServer) Retry(ctx context.Context, in *pb.DtmRequest) (*emptypb.Empty, error) {
+	r := svcRetry(TransFromDtmRequest(ctx, in))
+	return &emptypb.Empty{}, dtmgrpc.DtmError2GrpcError(r)
+}
+
+func timeToPb(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func transGlobalToPb(g *storage.TransGlobalStore) *pb.DtmTransGlobal {
+	return &pb.DtmTransGlobal{
+		Gid:              g.Gid,
+		TransType:        g.TransType,
+		Status:           g.Status,
+		Protocol:         g.Protocol,
+		QueryPrepared:    g.QueryPrepared,
+		CustomData:       g.CustomData,
+		Owner:            g.Owner,
+		ExtData:          g.ExtData,
+		NextCronInterval: g.NextCronInterval,
+		NextCronTime:     timeToPb(g.NextCronTime),
+		CreateTime:       timeToPb(g.CreateTime),
+		UpdateTime:       timeToPb(g.UpdateTime),
+		FinishTime:       timeToPb(g.FinishTime),
+		RollbackTime:     timeToPb(g.RollbackTime),
+		TransOptions: &pb.DtmTransOptions{
+			WaitResult:         g.WaitResult,
+			TimeoutToFail:      g.TimeoutToFail,
+			RetryInterval:      g.RetryInterval,
+			PassthroughHeaders: g.PassthroughHeaders,
+			BranchHeaders:      g.BranchHeaders,
+			RequestTimeout:     g.RequestTimeout,
+		},
+	}
+}
+
+func transBranchToPb(b *storage.TransBranchStore) *pb.DtmTransBranch {
+	return &pb.DtmTransBranch{
+		Gid:          b.Gid,
+		URL:          b.URL,
+		BinData:      b.BinData,
+		BranchID:     b.BranchID,
+		Op:           b.Op,
+		Status:       b.Status,
+		CreateTime:   timeToPb(b.CreateTime),
+		UpdateTime:   timeToPb(b.UpdateTime),
+		FinishTime:   timeToPb(b.FinishTime),
+		RollbackTime: timeToPb(b.RollbackTime),
+	}
+}
+
+func transesFromDtmBatchRequest(ctx context.Context, in *pb.DtmBatchRequest) []*TransGlobal {
+	ts := []*TransGlobal{}
+	for _, r := range in.Requests {
+		ts = append(ts, TransFromDtmRequest(ctx, r))
+	}
+	return ts
+}
+
+func batchReply(results []dtmimp.BatchResult) *pb.DtmBatchReply {
+	reply := &pb.DtmBatchReply{}
+	for _, r := range results {
+		reply.Results = append(reply.Results, &pb.DtmBatchResult{Gid: r.Gid, DtmResult: r.DtmResult, Message: r.Message})
+	}
+	return reply
+}
@@ -13,6 +13,8 @@ import (
 
 	"github.com/dtm-labs/dtm/dtmcli"
 	"github.com/dtm-labs/dtm/dtmcli/dtmimp"
+	"github.com/dtm-labs/dtm/dtmcli/logger"
+	"github.com/dtm-labs/dtm/dtmsvr/storage"
 	"github.com/dtm-labs/dtm/dtmutil"
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -22,14 +24,18 @@ func addRoute(engine *gin.Engine) {
 	engine.GET("/api/dtmsvr/newGid", dtmutil.WrapHandler2(newGid))
 	engine.POST("/api/dtmsvr/prepare", dtmutil.WrapHandler2(prepare))
 	engine.POST("/api/dtmsvr/submit", dtmutil.WrapHandler2(submit))
+	engine.POST("/api/dtmsvr/prepareBatch", dtmutil.WrapHandler2(prepareBatch))
+	engine.POST("/api/dtmsvr/submitBatch", dtmutil.WrapHandler2(submitBatch))
 	engine.POST("/api/dtmsvr/abort", dtmutil.WrapHandler2(abort))
 	engine.POST("/api/dtmsvr/forceStop", dtmutil.WrapHandler2(forceStop)) // change global status to failed can stop trigger (Use with caution in production environment)
+	engine.POST("/api/dtmsvr/retry", dtmutil.WrapHandler2(retry))
 	engine.POST("/api/dtmsvr/registerBranch", dtmutil.WrapHandler2(registerBranch))
 	engine.POST("/api/dtmsvr/registerXaBranch", dtmutil.WrapHandler2(registerBranch))  // compatible for old sdk
 	engine.POST("/api/dtmsvr/registerTccBranch", dtmutil.WrapHandler2(registerBranch)) // compatible for old sdk
 	engine.GET("/api/dtmsvr/query", dtmutil.WrapHandler2(query))
 	engine.GET("/api/dtmsvr/all", dtmutil.WrapHandler2(all))
 	engine.GET("/api/dtmsvr/resetCronTime", dtmutil.WrapHandler2(resetCronTime))
+	engine.GET("/api/dtmsvr/watch", watch) // server-sent events, not wrapped
 
 	// add prometheus exporter
 	h := promhttp.Handler()
@@ -51,6 +57,14 @@ func submit(c *gin.Context) interface{} {
 	return svcSubmit(TransFromContext(c))
 }
 
+func prepareBatch(c *gin.Context) interface{} {
+	return map[string]interface{}{"results": svcPrepareBatch(TransesFromContext(c))}
+}
+
+func submitBatch(c *gin.Context) interface{} {
+	return map[string]interface{}{"results": svcSubmitBatch(TransesFromContext(c))}
+}
+
 func abort(c *gin.Context) interface{} {
 	return svcAbort(TransFromContext(c))
 }
@@ -59,6 +73,10 @@ func forceStop(c *gin.Context) interface{} {
 	return svcForceStop(TransFromContext(c))
 }
 
+func retry(c *gin.Context) interface{} {
+	return svcRetry(TransFromContext(c))
+}
+
 func registerBranch(c *gin.Context) interface{} {
 	data := map[string]string{}
 	err := c.BindJSON(&data)
@@ -69,7 +87,7 @@ func registerBranch(c *gin.Context) interface{} {
 		Status:   dtmcli.StatusPrepared,
 		BinData:  []byte(data["data"]),
 	}
-	return svcRegisterBranch(data["trans_type"], &branch, data)
+	return svcRegisterBranch(c.Request.Context(), data["trans_type"], &branch, data)
 }
 
 func query(c *gin.Context) interface{} {
@@ -77,15 +95,28 @@ func query(c *gin.Context) interface{} {
 	if gid == "" {
 		return errors.New("no gid specified")
 	}
-	trans := GetStore().FindTransGlobalStore(gid)
-	branches := GetStore().FindBranches(gid)
+	ctx := storage.WithStaleReads(c.Request.Context())
+	trans, err := GetStoreV2().FindTransGlobalStoreContext(ctx, gid)
+	if err != nil && err != storage.ErrNotFound {
+		return err
+	}
+	branches, err := GetStoreV2().FindBranchesContext(ctx, gid)
+	if err == nil {
+		err = readablePayloads(branches)
+	}
+	if err != nil {
+		return err
+	}
 	return map[string]interface{}{"transaction": trans, "branches": branches}
 }
 
 func all(c *gin.Context) interface{} {
 	position := c.Query("position")
 	sLimit := dtmimp.OrString(c.Query("limit"), "100")
-	globals := GetStore().ScanTransGlobalStores(&position, int64(dtmimp.MustAtoi(sLimit)))
+	globals, err := GetStoreV2().ScanTransGlobalStoresContext(storage.WithStaleReads(c.Request.Context()), &position, int64(dtmimp.MustAtoi(sLimit)))
+	if err != nil {
+		return err
+	}
 	return map[string]interface{}{"transactions": globals, "next_position": position}
 }
 
@@ -96,9 +127,25 @@ func resetCronTime(c *gin.Context) interface{} {
 	sLimit := dtmimp.OrString(c.Query("limit"), "100")
 	timeout := time.Duration(dtmimp.MustAtoi(sTimeoutSecond)) * time.Second
 
-	succeedCount, hasRemaining, err := GetStore().ResetCronTime(timeout, int64(dtmimp.MustAtoi(sLimit)))
+	succeedCount, hasRemaining, err := GetStoreV2().ResetCronTimeContext(c.Request.Context(), timeout, int64(dtmimp.MustAtoi(sLimit)))
 	if err != nil {
 		return err
 	}
 	return map[string]interface{}{"has_remaining": hasRemaining, "succeed_count": succeedCount}
 }
+
+// watch streams the status changes of gid, or of all trans with trans_type, as server-sent events
+func watch(c *gin.Context) {
+	err := svcWatch(c.Query("gid"), c.Query("trans_type"), c.Request.Context().Done(), func(e *watchEvent) error {
+		c.SSEvent("status", e)
+		c.Writer.Flush()
+		return c.Request.Context().Err()
+	})
+	if errors.Is(err, errWatcherDropped) { // the client should watch again, and query the trans it cares about
+		c.SSEvent("error", err.Error())
+		c.Writer.Flush()
+	}
+	if err != nil {
+		logger.Infof("watch ended: %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package dtmsvr
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -25,6 +26,8 @@ func addJrpcRouter(engine *gin.Engine) {
 		"newGid":         jrpcNewGid,
 		"prepare":        jrpcPrepare,
 		"submit":         jrpcSubmit,
+		"prepareBatch":   jrpcPrepareBatch,
+		"submitBatch":    jrpcSubmitBatch,
 		"abort":          jrpcAbort,
 		"registerBranch": jrpcRegisterBranch,
 	}
@@ -119,6 +122,24 @@ func jrpcSubmit(params interface{}) interface{} {
 	return svcSubmit(TransFromJrpcParams(params))
 }
 
+// TransesFromJrpcParams construct trans from jrpc params of a batch request
+func TransesFromJrpcParams(params interface{}) []*TransGlobal {
+	ts := []*TransGlobal{}
+	dtmimp.MustRemarshal(params, &ts)
+	for _, t := range ts {
+		t.setupPayloads()
+	}
+	return ts
+}
+
+func jrpcPrepareBatch(params interface{}) interface{} {
+	return map[string]interface{}{"results": svcPrepareBatch(TransesFromJrpcParams(params))}
+}
+
+func jrpcSubmitBatch(params interface{}) interface{} {
+	return map[string]interface{}{"results": svcSubmitBatch(TransesFromJrpcParams(params))}
+}
+
 func jrpcAbort(params interface{}) interface{} {
 	return svcAbort(TransFromJrpcParams(params))
 }
@@ -132,5 +153,5 @@ func jrpcRegisterBranch(params interface{}) interface{} {
 		Status:   dtmcli.StatusPrepared,
 		BinData:  []byte(data["data"]),
 	}
-	return svcRegisterBranch(data["trans_type"], &branch, data)
+	return svcRegisterBranch(context.Background(), data["trans_type"], &branch, data)
 }
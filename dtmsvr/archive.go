@@ -0,0 +1,41 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package dtmsvr
+
+import (
+	"time"
+
+	"github.com/dtm-labs/dtm/dtmcli/logger"
+	"github.com/dtm-labs/dtm/dtmsvr/storage/sql"
+)
+
+// archiveFinished archives the finished trans of mysql/postgres in batches.
+// it sleeps Archive.Interval when there are no more trans to archive
+func archiveFinished() {
+	for {
+		if archiveFinishedOnce() < conf.Store.Archive.BatchSize {
+			time.Sleep(time.Duration(conf.Store.Archive.Interval) * time.Second)
+		}
+	}
+}
+
+func archiveFinishedOnce() (archived int64) {
+	defer handlePanic(nil)
+	ac := conf.Store.Archive
+	before := time.Now().Add(-time.Duration(ac.Expire) * time.Second)
+	archived, err := GetStoreV2().(*sql.Store).ArchiveFinished(before, ac.BatchSize)
+	if err != nil {
+		logger.Errorf("archive finished trans to %s failed: %v", ac.Target, err)
+		archiveFailedTotal.Inc()
+		return
+	}
+	if archived > 0 {
+		logger.Infof("archived %d finished trans to %s", archived, ac.Target)
+		archivedTotal.WithLabelValues(ac.Target).Add(float64(archived))
+	}
+	return
+}
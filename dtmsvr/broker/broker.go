@@ -0,0 +1,63 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package broker
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Message is the content published to a broker for a msg branch
+type Message struct {
+	Gid       string            `json:"gid"`
+	TransType string            `json:"trans_type"`
+	BranchID  string            `json:"branch_id"`
+	Op        string            `json:"op"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Payload   []byte            `json:"payload"`
+}
+
+// Key returns an id unique for the branch. consumers can use it to drop duplicated messages
+func (m *Message) Key() string {
+	return fmt.Sprintf("%s-%s-%s", m.Gid, m.BranchID, m.Op)
+}
+
+// Producer publishes msg branches to a broker.
+// Publish will be retried by dtm until nil is returned, so a producer should be idempotent on Message.Key
+type Producer interface {
+	// Publish sends msg to target. target is the part of branch url after "scheme://"
+	Publish(target string, msg *Message) error
+}
+
+var producers sync.Map
+
+// Register registers a producer for the url scheme. only "redis-stream" is built into dtm, enabled by config.MsgBroker.
+// producers of other brokers, such as "kafka" or "nats", are not shipped with dtm. they can be added by a package
+// calling Register in its init function, which is linked into dtm by a blank import in stores.go
+func Register(scheme string, producer Producer) {
+	producers.Store(scheme, producer)
+}
+
+// Unregister removes the producer for the url scheme
+func Unregister(scheme string) {
+	producers.Delete(scheme)
+}
+
+// Lookup returns the producer and target for a branch url.
+// if the scheme of url is not registered, nil producer is returned
+func Lookup(uri string) (Producer, string) {
+	pos := strings.Index(uri, "://")
+	if pos <= 0 {
+		return nil, ""
+	}
+	p, ok := producers.Load(uri[:pos])
+	if !ok {
+		return nil, ""
+	}
+	return p.(Producer), uri[pos+3:]
+}
@@ -0,0 +1,48 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package broker
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookup(t *testing.T) {
+	p := NewMemoryProducer()
+	Register("kafka", p)
+	defer Unregister("kafka")
+
+	found, target := Lookup("kafka://orders")
+	assert.Equal(t, p, found)
+	assert.Equal(t, "orders", target)
+
+	found, _ = Lookup("nats://orders")
+	assert.Nil(t, found)
+	found, _ = Lookup("http://localhost:8080/api")
+	assert.Nil(t, found)
+	found, _ = Lookup("localhost:58081/busi.Busi/TransIn")
+	assert.Nil(t, found)
+}
+
+func TestMemoryProducer(t *testing.T) {
+	p := NewMemoryProducer()
+	msg := &Message{Gid: "gid1", TransType: "msg", BranchID: "01", Op: "action", Payload: []byte("{}")}
+	assert.Nil(t, p.Publish("orders", msg))
+	assert.Nil(t, p.Publish("orders", msg))
+	assert.Equal(t, 1, len(p.Messages("orders")))
+
+	msg2 := *msg
+	msg2.BranchID = "02"
+	assert.Nil(t, p.Publish("orders", &msg2))
+	assert.Equal(t, 2, len(p.Messages("orders")))
+	assert.Equal(t, 0, len(p.Messages("payments")))
+
+	p.Err = errors.New("broker down")
+	assert.Error(t, p.Publish("orders", msg))
+}
@@ -0,0 +1,48 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package broker
+
+import "sync"
+
+// MemoryProducer is an in-process producer. it is a stand-in for real brokers in tests and local development
+type MemoryProducer struct {
+	mu       sync.Mutex
+	keys     map[string]bool
+	messages map[string][]Message
+	// Err will be returned by Publish if it is not nil
+	Err error
+}
+
+// NewMemoryProducer returns an empty MemoryProducer
+func NewMemoryProducer() *MemoryProducer {
+	return &MemoryProducer{
+		keys:     map[string]bool{},
+		messages: map[string][]Message{},
+	}
+}
+
+// Publish appends msg to target, a message with the same key is published only once
+func (p *MemoryProducer) Publish(target string, msg *Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.Err != nil {
+		return p.Err
+	}
+	k := target + "/" + msg.Key()
+	if !p.keys[k] {
+		p.keys[k] = true
+		p.messages[target] = append(p.messages[target], *msg)
+	}
+	return nil
+}
+
+// Messages returns the messages published to target
+func (p *MemoryProducer) Messages(target string) []Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Message{}, p.messages[target]...)
+}
@@ -0,0 +1,60 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package broker
+
+import (
+	"context"
+	"time"
+
+	"github.com/dtm-labs/dtm/dtmcli/dtmimp"
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisStreamProducer publishes messages to redis streams with XADD. url format: redis-stream://key
+type RedisStreamProducer struct {
+	client   redis.UniversalClient
+	maxLen   int64
+	dedupTTL time.Duration
+}
+
+// NewRedisStreamProducer creates a RedisStreamProducer.
+// maxLen is the approximate MAXLEN of the stream, 0 for no trimming.
+// dedupTTL is how long the published key is remembered to avoid duplicated XADD on retry, default to 1 day
+func NewRedisStreamProducer(client redis.UniversalClient, maxLen int64, dedupTTL time.Duration) *RedisStreamProducer {
+	if dedupTTL < time.Second {
+		dedupTTL = 24 * time.Hour
+	}
+	return &RedisStreamProducer{client: client, maxLen: maxLen, dedupTTL: dedupTTL}
+}
+
+// Publish adds msg to the stream. the dedup key and the stream entry are written atomically.
+// the dedup key uses target as hash tag, so it is in the same slot as the stream in redis cluster
+func (p *RedisStreamProducer) Publish(target string, msg *Message) error {
+	headers := ""
+	if len(msg.Headers) > 0 {
+		headers = dtmimp.MustMarshalString(msg.Headers)
+	}
+	_, err := p.client.Eval(context.Background(), `-- RedisStreamPublish
+if redis.call('EXISTS', KEYS[2]) == 1 then
+	return 'DUPLICATED'
+end
+local id
+if tonumber(ARGV[2]) > 0 then
+	id = redis.call('XADD', KEYS[1], 'MAXLEN', '~', ARGV[2], '*', 'key', ARGV[3], 'gid', ARGV[4], 'trans_type', ARGV[5], 'branch_id', ARGV[6], 'op', ARGV[7], 'headers', ARGV[8], 'payload', ARGV[9])
+else
+	id = redis.call('XADD', KEYS[1], '*', 'key', ARGV[3], 'gid', ARGV[4], 'trans_type', ARGV[5], 'branch_id', ARGV[6], 'op', ARGV[7], 'headers', ARGV[8], 'payload', ARGV[9])
+end
+-- a script is not rolled back on error, so the dedup key is set only after XADD succeeds
+redis.call('SET', KEYS[2], '1', 'EX', ARGV[1])
+return id
+`, []string{target, "{" + target + "}_dtm_pub_" + msg.Key()},
+		int64(p.dedupTTL/time.Second), p.maxLen, msg.Key(), msg.Gid, msg.TransType, msg.BranchID, msg.Op, headers, msg.Payload).Result()
+	if err == redis.Nil {
+		err = nil
+	}
+	return err
+}
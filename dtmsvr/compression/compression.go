@@ -0,0 +1,115 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+type codec struct {
+	magic      []byte
+	compress   func(data []byte) ([]byte, error)
+	decompress func(data []byte) ([]byte, error)
+}
+
+// the encoder and decoder of zstd are safe for the concurrent EncodeAll/DecodeAll
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil)
+	zstdDecoder, _ = zstd.NewReader(nil)
+)
+
+// the magic of every algorithm is saved as the prefix of the compressed data,
+// so the data can be decompressed after the algorithm in config is changed
+var codecs = map[string]*codec{
+	"zstd": {
+		magic: []byte("\x00dtmzst\x01"),
+		compress: func(data []byte) ([]byte, error) {
+			return zstdEncoder.EncodeAll(data, nil), nil
+		},
+		decompress: func(data []byte) ([]byte, error) {
+			return zstdDecoder.DecodeAll(data, nil)
+		},
+	},
+	"gzip": {
+		magic: []byte("\x00dtmgzp\x01"),
+		compress: func(data []byte) ([]byte, error) {
+			var buf bytes.Buffer
+			zw := gzip.NewWriter(&buf)
+			if _, err := zw.Write(data); err != nil {
+				return nil, err
+			}
+			if err := zw.Close(); err != nil {
+				return nil, err
+			}
+			return buf.Bytes(), nil
+		},
+		decompress: func(data []byte) ([]byte, error) {
+			zr, err := gzip.NewReader(bytes.NewReader(data))
+			if err != nil {
+				return nil, err
+			}
+			defer zr.Close()
+			return ioutil.ReadAll(zr)
+		},
+	},
+}
+
+// Algorithms returns the sorted names of the supported algorithms
+func Algorithms() []string {
+	names := []string{}
+	for name := range codecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Compress compresses data with algorithm. data is returned as is if it is not shrunk by the compression
+func Compress(algorithm string, data []byte) ([]byte, error) {
+	c := codecs[algorithm]
+	if c == nil {
+		return nil, fmt.Errorf("compression algorithm %s not supported", algorithm)
+	}
+	compressed, err := c.compress(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(c.magic)+len(compressed) >= len(data) {
+		return data, nil
+	}
+	return append(append(make([]byte, 0, len(c.magic)+len(compressed)), c.magic...), compressed...), nil
+}
+
+// Decompress decompresses data returned by Compress. data not compressed is returned as is
+func Decompress(data []byte) ([]byte, error) {
+	for name, c := range codecs {
+		if bytes.HasPrefix(data, c.magic) {
+			plain, err := c.decompress(data[len(c.magic):])
+			if err != nil {
+				return nil, fmt.Errorf("decompress %s data failed: %w", name, err)
+			}
+			return plain, nil
+		}
+	}
+	return data, nil
+}
+
+// IsCompressed checks whether data is compressed by Compress
+func IsCompressed(data []byte) bool {
+	for _, c := range codecs {
+		if bytes.HasPrefix(data, c.magic) {
+			return true
+		}
+	}
+	return false
+}
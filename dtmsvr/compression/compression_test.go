@@ -0,0 +1,43 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package compression
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompress(t *testing.T) {
+	assert.Equal(t, []string{"gzip", "zstd"}, Algorithms())
+	plain := []byte(strings.Repeat(`{"amount":30,"user_id":1}`, 100))
+	for _, algorithm := range Algorithms() {
+		data, err := Compress(algorithm, plain)
+		assert.Nil(t, err)
+		assert.True(t, IsCompressed(data))
+		assert.Less(t, len(data), len(plain))
+		decompressed, err := Decompress(data)
+		assert.Nil(t, err)
+		assert.Equal(t, plain, decompressed)
+
+		// data not shrunk is kept uncompressed
+		data, err = Compress(algorithm, []byte(`{"a":1}`))
+		assert.Nil(t, err)
+		assert.Equal(t, `{"a":1}`, string(data))
+	}
+
+	data, err := Decompress(plain)
+	assert.Nil(t, err)
+	assert.Equal(t, plain, data)
+	assert.False(t, IsCompressed(plain))
+
+	_, err = Compress("lz4", plain)
+	assert.Error(t, err)
+	_, err = Decompress([]byte("\x00dtmgzp\x01bad"))
+	assert.Error(t, err)
+}
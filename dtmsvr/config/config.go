@@ -20,6 +20,12 @@ const (
 	BoltDb = "boltdb"
 	// Postgres is postgres driver
 	Postgres = "postgres"
+	// Mongo is mongo driver
+	Mongo = "mongo"
+	// Etcd is etcd driver
+	Etcd = "etcd"
+	// Sqlite is sqlite driver
+	Sqlite = "sqlite"
 )
 
 // MicroService config type for micro service
@@ -36,26 +42,55 @@ type Log struct {
 	RotationConfigJSON string `yaml:"RotationConfigJSON" default:"{}"`
 }
 
+// Archive config for archiving the finished trans of mysql/postgres/sqlite
+type Archive struct {
+	Expire             int64  `yaml:"Expire"`                  // finished trans will be archived after this seconds. 0 to disable archiving
+	Target             string `yaml:"Target" default:"delete"` // delete | table | file
+	TransGlobalTable   string `yaml:"TransGlobalTable" default:"dtm.trans_global_archive"`
+	TransBranchOpTable string `yaml:"TransBranchOpTable" default:"dtm.trans_branch_op_archive"`
+	Dir                string `yaml:"Dir" default:"./archive"` // dir of the gzipped files, for Target file
+	BatchSize          int64  `yaml:"BatchSize" default:"100"`
+	Interval           int64  `yaml:"Interval" default:"60"` // seconds to sleep when no more trans to archive
+}
+
 // Store defines storage relevant info
 type Store struct {
-	Driver             string `yaml:"Driver" default:"boltdb"`
-	Host               string `yaml:"Host"`
-	Port               int64  `yaml:"Port"`
-	User               string `yaml:"User"`
-	Password           string `yaml:"Password"`
-	MaxOpenConns       int64  `yaml:"MaxOpenConns" default:"500"`
-	MaxIdleConns       int64  `yaml:"MaxIdleConns" default:"500"`
-	ConnMaxLifeTime    int64  `yaml:"ConnMaxLifeTime" default:"5"`
-	DataExpire         int64  `yaml:"DataExpire" default:"604800"`        // Trans data will expire in 7 days. only for redis/boltdb.
-	FinishedDataExpire int64  `yaml:"FinishedDataExpire" default:"86400"` // finished Trans data will expire in 1 days. only for redis.
-	RedisPrefix        string `yaml:"RedisPrefix" default:"{a}"`          // Redis storage prefix. store data to only one slot in cluster
-	TransGlobalTable   string `yaml:"TransGlobalTable" default:"dtm.trans_global"`
-	TransBranchOpTable string `yaml:"TransBranchOpTable" default:"dtm.trans_branch_op"`
+	Driver             string  `yaml:"Driver" default:"boltdb"`
+	Host               string  `yaml:"Host"`
+	Port               int64   `yaml:"Port"`
+	User               string  `yaml:"User"`
+	Password           string  `yaml:"Password"`
+	Endpoints          string  `yaml:"Endpoints"` // comma separated endpoints of the etcd cluster, the redis cluster or the redis sentinels, like 'host1:2379,host2:2379'. Host:Port is used if empty. only for etcd/redis
+	MaxOpenConns       int64   `yaml:"MaxOpenConns" default:"500"`
+	MaxIdleConns       int64   `yaml:"MaxIdleConns" default:"500"`
+	ConnMaxLifeTime    int64   `yaml:"ConnMaxLifeTime" default:"5"`
+	DataExpire         int64   `yaml:"DataExpire" default:"604800"`        // Trans data will expire in 7 days. only for redis/boltdb/mongo/etcd.
+	FinishedDataExpire int64   `yaml:"FinishedDataExpire" default:"86400"` // finished Trans data will expire in 1 days. only for redis/mongo/etcd.
+	RedisPrefix        string  `yaml:"RedisPrefix" default:"{a}"`          // Redis storage prefix. store data to only one slot in cluster
+	RedisMode          string  `yaml:"RedisMode" default:"standalone"`     // standalone | sentinel | cluster
+	RedisMasterName    string  `yaml:"RedisMasterName"`                    // name of the master monitored by the sentinels. only for sentinel mode
+	RedisKeyShards     int64   `yaml:"RedisKeyShards"`                     // spread the trans over this many hash tags derived from gid. 0 to store data with only the hash tag of RedisPrefix
+	EtcdPrefix         string  `yaml:"EtcdPrefix" default:"/dtm"`          // prefix of the etcd keys
+	TransGlobalTable   string  `yaml:"TransGlobalTable" default:"dtm.trans_global"`
+	TransBranchOpTable string  `yaml:"TransBranchOpTable" default:"dtm.trans_branch_op"`
+	CronInstanceTable  string  `yaml:"CronInstanceTable" default:"dtm.cron_instance"` // only for sharded cron
+	Archive            Archive `yaml:"Archive"`                                       // only for mysql/postgres/sqlite
+	Plugins            string  `yaml:"Plugins"`                                       // comma separated paths of the go plugins, which register the stores of other drivers or the key providers of encryption
+	Options            string  `yaml:"Options" default:"{}"`                          // driver-specific options in json, for the stores of plugins
+	ReadReplicas       string  `yaml:"ReadReplicas"`                                  // comma separated read replicas, like 'host1:3306,host2:3306', sharing User/Password. used by query/list of the admin apis. only for mysql/postgres
 }
 
-// IsDB checks config driver is mysql or postgres
+// IsDB checks config driver is mysql, postgres or sqlite
 func (s *Store) IsDB() bool {
-	return s.Driver == dtmcli.DBTypeMysql || s.Driver == dtmcli.DBTypePostgres
+	return s.Driver == dtmcli.DBTypeMysql || s.Driver == dtmcli.DBTypePostgres || s.Driver == dtmcli.DBTypeSqlite
+}
+
+// UnmarshalOptions parses the driver-specific options into v
+func (s *Store) UnmarshalOptions(v interface{}) error {
+	if s.Options == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(s.Options), v)
 }
 
 // GetDBConf returns db conf info
@@ -69,18 +104,56 @@ func (s *Store) GetDBConf() dtmcli.DBConf {
 	}
 }
 
+// MsgBroker defines the brokers that msg branches can publish to
+type MsgBroker struct {
+	RedisHost     string `yaml:"RedisHost"` // enable redis-stream:// branch urls if not empty
+	RedisPort     int64  `yaml:"RedisPort" default:"6379"`
+	RedisUser     string `yaml:"RedisUser"`
+	RedisPassword string `yaml:"RedisPassword"`
+	RedisMaxLen   int64  `yaml:"RedisMaxLen"` // approximate max length of streams. 0 for no trimming
+}
+
+// Encryption config for the envelope encryption of the branch payloads and passthrough headers at rest
+type Encryption struct {
+	Provider        string `yaml:"Provider"`                       // local | a key provider registered by encryption.RegisterProvider. empty to disable encryption
+	KeyFile         string `yaml:"KeyFile"`                        // file of the master keys, only for the local provider
+	Options         string `yaml:"Options" default:"{}"`           // provider-specific options in json, like the key id and region of a KMS
+	DataKeyLifetime int64  `yaml:"DataKeyLifetime" default:"3600"` // seconds a data key is used to encrypt, before a new data key is generated
+}
+
+// UnmarshalOptions parses the provider-specific options into v
+func (e *Encryption) UnmarshalOptions(v interface{}) error {
+	if e.Options == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(e.Options), v)
+}
+
+// Compression config for the compression of the branch payloads at rest
+type Compression struct {
+	Algorithm string `yaml:"Algorithm"`                // zstd | gzip. empty to disable compression
+	Threshold int64  `yaml:"Threshold" default:"1024"` // payloads shorter than this bytes are not compressed
+	Dedup     int64  `yaml:"Dedup"`                    // 1 to save the payload of a saga step once, shared by its action and compensate branches
+}
+
 type configType struct {
 	Store                         Store        `yaml:"Store"`
 	TransCronInterval             int64        `yaml:"TransCronInterval" default:"3"`
 	TimeoutToFail                 int64        `yaml:"TimeoutToFail" default:"35"`
 	RetryInterval                 int64        `yaml:"RetryInterval" default:"10"`
+	CronLeaseInterval             int64        `yaml:"CronLeaseInterval" default:"10"`
 	RequestTimeout                int64        `yaml:"RequestTimeout" default:"3"`
 	HTTPPort                      int64        `yaml:"HttpPort" default:"36789"`
 	GrpcPort                      int64        `yaml:"GrpcPort" default:"36790"`
 	JSONRPCPort                   int64        `yaml:"JsonRpcPort" default:"36791"`
 	MicroService                  MicroService `yaml:"MicroService"`
+	MsgBroker                     MsgBroker    `yaml:"MsgBroker"`
+	Encryption                    Encryption   `yaml:"Encryption"`
+	Compression                   Compression  `yaml:"Compression"`
 	UpdateBranchSync              int64        `yaml:"UpdateBranchSync"`
 	UpdateBranchAsyncGoroutineNum int64        `yaml:"UpdateBranchAsyncGoroutineNum" default:"1"`
+	CronGoroutineNum              int64        `yaml:"CronGoroutineNum" default:"1"`
+	CronShards                    int64        `yaml:"CronShards"`
 	LogLevel                      string       `yaml:"LogLevel" default:"info"`
 	Log                           Log          `yaml:"Log"`
 }
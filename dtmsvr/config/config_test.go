@@ -34,6 +34,10 @@ func TestCheckConfig(t *testing.T) {
 	assert.Equal(t, timeoutToFailErr, timeoutToFailExpect)
 
 	conf.TimeoutToFail = 20
+	conf.CronLeaseInterval = 1
+	assert.Equal(t, errors.New("CronLeaseInterval should not be less than 3"), checkConfig(&conf))
+
+	conf.CronLeaseInterval = 10
 	driverErr := checkConfig(&conf)
 	assert.Equal(t, driverErr, nil)
 
@@ -52,12 +56,56 @@ func TestCheckConfig(t *testing.T) {
 	userExpect := errors.New("Db user not valid ")
 	assert.Equal(t, userErr, userExpect)
 
+	conf.Store = Store{Driver: Mysql, Host: "127.0.0.1", Port: 8686, User: "root", Archive: Archive{Expire: 86400, Target: "unknown"}}
+	assert.Equal(t, errors.New("Archive target should be one of delete|table|file"), checkConfig(&conf))
+
 	conf.Store = Store{Driver: Redis, Host: "", Port: 8686}
 	assert.Equal(t, errors.New("Redis host not valid"), checkConfig(&conf))
 
 	conf.Store = Store{Driver: Redis, Host: "127.0.0.1", Port: 0}
 	assert.Equal(t, errors.New("Redis port not valid"), checkConfig(&conf))
 
+	conf.Store = Store{Driver: Redis, Host: "127.0.0.1", Port: 6379, RedisMode: "unknown"}
+	assert.Equal(t, errors.New("RedisMode should be one of standalone|sentinel|cluster"), checkConfig(&conf))
+
+	conf.Store = Store{Driver: Redis, Endpoints: "127.0.0.1:26379", RedisMode: "sentinel"}
+	assert.Equal(t, errors.New("RedisMasterName should be specified for sentinel mode"), checkConfig(&conf))
+
+	conf.Store = Store{Driver: Redis, Endpoints: "127.0.0.1:7000,127.0.0.1:7001", RedisMode: "cluster", RedisPrefix: "{a}", RedisKeyShards: 16}
+	assert.Equal(t, errors.New("RedisPrefix should not contain a hash tag when RedisKeyShards is specified"), checkConfig(&conf))
+
+	conf.Store = Store{Driver: Redis, Endpoints: "127.0.0.1:7000,127.0.0.1:7001", RedisMode: "cluster", RedisPrefix: "dtm", RedisKeyShards: 16}
+	assert.Nil(t, checkConfig(&conf))
+
+	conf.Store = Store{Driver: Mongo, Host: "", Port: 27017}
+	assert.Equal(t, errors.New("Mongo host not valid"), checkConfig(&conf))
+
+	conf.Store = Store{Driver: Mongo, Host: "127.0.0.1", Port: 0}
+	assert.Equal(t, errors.New("Mongo port not valid"), checkConfig(&conf))
+
+	conf.Store = Store{Driver: Sqlite, Host: ""}
+	assert.Equal(t, errors.New("Sqlite dir not valid"), checkConfig(&conf))
+
+	conf.Store = Store{Driver: Sqlite, Host: "./data"}
+	assert.Nil(t, checkConfig(&conf))
+
+	conf.Store = Store{Driver: Etcd, Host: "127.0.0.1", Port: 0}
+	assert.Equal(t, errors.New("Etcd endpoints not valid"), checkConfig(&conf))
+
+	conf.Store = Store{Driver: Etcd, Endpoints: "127.0.0.1:2379,127.0.0.1:22379"}
+	assert.Nil(t, checkConfig(&conf))
+
+	conf.Encryption = Encryption{Provider: "local"}
+	assert.Equal(t, errors.New("KeyFile should be specified for the local key provider"), checkConfig(&conf))
+
+	conf.Encryption = Encryption{Provider: "local", KeyFile: "./dtm.keys"}
+	assert.Nil(t, checkConfig(&conf))
+
+	conf.Compression = Compression{Algorithm: "lz4"}
+	assert.Equal(t, errors.New("Compression.Algorithm should be zstd or gzip"), checkConfig(&conf))
+
+	conf.Compression = Compression{Algorithm: "zstd", Threshold: 1024}
+	assert.Nil(t, checkConfig(&conf))
 }
 
 func TestConfig(t *testing.T) {
@@ -82,3 +130,14 @@ func testConfigIntField(fd *int64, val int64, t *testing.T) {
 	assert.NotEqual(t, "", str)
 	*fd = old
 }
+
+func TestUnmarshalOptions(t *testing.T) {
+	opts := map[string]string{}
+	s := Store{}
+	assert.Nil(t, s.UnmarshalOptions(&opts))
+	s.Options = `{"region":"r1"}`
+	assert.Nil(t, s.UnmarshalOptions(&opts))
+	assert.Equal(t, "r1", opts["region"])
+	s.Options = "bad"
+	assert.Error(t, s.UnmarshalOptions(&opts))
+}
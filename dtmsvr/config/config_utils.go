@@ -65,6 +65,15 @@ func checkConfig(conf *configType) error {
 	if conf.TimeoutToFail < conf.RetryInterval {
 		return errors.New("TimeoutToFail should not be less than RetryInterval")
 	}
+	if conf.CronLeaseInterval < 3 {
+		return errors.New("CronLeaseInterval should not be less than 3")
+	}
+	if conf.Encryption.Provider == "local" && conf.Encryption.KeyFile == "" {
+		return errors.New("KeyFile should be specified for the local key provider")
+	}
+	if a := conf.Compression.Algorithm; a != "" && a != "zstd" && a != "gzip" {
+		return errors.New("Compression.Algorithm should be zstd or gzip")
+	}
 	switch conf.Store.Driver {
 	case BoltDb:
 		return nil
@@ -78,13 +87,43 @@ func checkConfig(conf *configType) error {
 		if conf.Store.User == "" {
 			return errors.New("Db user not valid ")
 		}
-	case Redis:
+		if target := conf.Store.Archive.Target; conf.Store.Archive.Expire > 0 && target != "delete" && target != "table" && target != "file" {
+			return errors.New("Archive target should be one of delete|table|file")
+		}
+	case Sqlite:
 		if conf.Store.Host == "" {
+			return errors.New("Sqlite dir not valid")
+		}
+		if target := conf.Store.Archive.Target; conf.Store.Archive.Expire > 0 && target != "delete" && target != "table" && target != "file" {
+			return errors.New("Archive target should be one of delete|table|file")
+		}
+	case Redis:
+		if conf.Store.Endpoints == "" && conf.Store.Host == "" {
 			return errors.New("Redis host not valid")
 		}
-		if conf.Store.Port == 0 {
+		if conf.Store.Endpoints == "" && conf.Store.Port == 0 {
 			return errors.New("Redis port not valid")
 		}
+		if mode := conf.Store.RedisMode; mode != "standalone" && mode != "sentinel" && mode != "cluster" {
+			return errors.New("RedisMode should be one of standalone|sentinel|cluster")
+		}
+		if conf.Store.RedisMode == "sentinel" && conf.Store.RedisMasterName == "" {
+			return errors.New("RedisMasterName should be specified for sentinel mode")
+		}
+		if conf.Store.RedisKeyShards > 0 && strings.ContainsAny(conf.Store.RedisPrefix, "{}") {
+			return errors.New("RedisPrefix should not contain a hash tag when RedisKeyShards is specified")
+		}
+	case Mongo:
+		if conf.Store.Host == "" {
+			return errors.New("Mongo host not valid")
+		}
+		if conf.Store.Port == 0 {
+			return errors.New("Mongo port not valid")
+		}
+	case Etcd:
+		if conf.Store.Endpoints == "" && (conf.Store.Host == "" || conf.Store.Port == 0) {
+			return errors.New("Etcd endpoints not valid")
+		}
 	}
 	return nil
 }
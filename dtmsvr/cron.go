@@ -7,6 +7,7 @@
 package dtmsvr
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"math/rand"
@@ -16,6 +17,7 @@ import (
 	"github.com/dtm-labs/dtm/dtmcli"
 	"github.com/dtm-labs/dtm/dtmcli/dtmimp"
 	"github.com/dtm-labs/dtm/dtmcli/logger"
+	"github.com/dtm-labs/dtm/dtmsvr/storage"
 )
 
 // NowForwardDuration will be set in test, trans may be timeout
@@ -32,32 +34,121 @@ func CronTransOnce() (gid string) {
 		return
 	}
 	gid = trans.Gid
-	trans.WaitResult = true
-	branches := GetStore().FindBranches(gid)
-	err := trans.Process(branches)
-	dtmimp.PanicIf(err != nil && !errors.Is(err, dtmcli.ErrFailure), err)
+	trans.processLocked()
 	return
 }
 
-// CronExpiredTrans cron expired trans, num == -1 indicate for ever
+// CronExpiredTrans cron expired trans, num == -1 indicate for ever.
+// trans are locked in batches, no more than the free workers, and processed by CronGoroutineNum workers
 func CronExpiredTrans(num int) {
+	workers := make(chan struct{}, dtmimp.If(conf.CronGoroutineNum > 1, conf.CronGoroutineNum, int64(1)).(int64)) // a busy worker holds a slot
 	for i := 0; i < num || num == -1; i++ {
-		gid := CronTransOnce()
-		if gid == "" && num != 1 {
-			sleepCronTime()
+		workers <- struct{}{} // wait for a free worker
+		limit := 1 + cap(workers) - len(workers)
+		trans := lockTransBatch(CronForwardDuration, int64(limit), currentShards())
+		if len(trans) == 0 {
+			<-workers
+			if num != 1 {
+				sleepCronTime()
+			}
+			continue
 		}
+		for range trans[1:] {
+			workers <- struct{}{} // never blocks, there are enough free workers
+		}
+		cronWorkerBusy.Set(float64(len(workers)))
+		for _, t := range trans {
+			go func(t *TransGlobal) {
+				defer func() {
+					<-workers
+					cronWorkerBusy.Set(float64(len(workers)))
+				}()
+				defer handlePanic(nil)
+				t.processLocked()
+			}(t)
+		}
+	}
+	for i := 0; i < cap(workers); i++ { // wait for all workers to finish
+		workers <- struct{}{}
 	}
 }
 
+// processLocked processes the trans locked by cron
+func (t *TransGlobal) processLocked() {
+	defer t.holdLease()()
+	t.WaitResult = true
+	branches := GetStore().FindBranches(t.Gid)
+	err := t.Process(branches)
+	dtmimp.PanicIf(err != nil && !errors.Is(err, dtmcli.ErrFailure) && !errors.Is(err, errLeaseLost), err)
+}
+
 func lockOneTrans(expireIn time.Duration) *TransGlobal {
 	global := GetStore().LockOneGlobalTrans(expireIn)
 	if global == nil {
 		return nil
 	}
 	logger.Infof("cron job return a trans: %s", global.String())
+	cronLockedTotal.Inc()
 	return &TransGlobal{TransGlobalStore: *global}
 }
 
+func lockTransBatch(expireIn time.Duration, limit int64, shards storage.ShardFilter) (trans []*TransGlobal) {
+	defer handlePanic(nil)
+	if shards.Total > 0 && len(shards.Owned) == 0 { // more instances than shards
+		return
+	}
+	globals := GetStore().LockGlobalTransBatch(expireIn, limit, shards)
+	for _, global := range globals {
+		logger.Infof("cron job return a trans: %s", global.String())
+		trans = append(trans, &TransGlobal{TransGlobalStore: *global})
+	}
+	cronLockedTotal.Add(float64(len(globals)))
+	return
+}
+
+// errLeaseLost the processing is canceled because the lease is lost, and the trans may be processed by another owner
+var errLeaseLost = errors.New("lease lost")
+
+// holdLease renews the lease of the trans locked by cron until the returned release is called.
+// the processing is canceled when the lease is lost, and the writes of status are fenced by the owner
+func (t *TransGlobal) holdLease() (release func()) {
+	t.leased = true
+	t.updateBranchSync = true // the async updates of branches are not fenced
+	ctx, cancel := context.WithCancel(context.Background())
+	t.ctx = storage.WithOwner(ctx, t.Owner)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func(gid string, owner string) {
+		defer close(stopped)
+		interval := time.Duration(conf.CronLeaseInterval) * time.Second / 3
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(interval):
+			}
+			err := dtmimp.CatchP(func() {
+				dtmimp.E2P(GetStore().RenewLease(gid, owner))
+			})
+			if errors.Is(err, storage.ErrNotFound) { // lease lost, or trans finished
+				logger.Infof("lease of gid: %s owner: %s not renewed, processing canceled", gid, owner)
+				cancel()
+				return
+			} else if err != nil {
+				logger.Errorf("RenewLease for gid: %s owner: %s failed: %v", gid, owner, err)
+			}
+		}
+	}(t.Gid, t.Owner)
+	return func() {
+		close(done)
+		<-stopped
+		cancel()
+		t.leased = false
+		GetStore().ReleaseLease(&t.TransGlobalStore)
+		scheduleWakeup(&t.TransGlobalStore)
+	}
+}
+
 func handlePanic(perr *error) {
 	if err := recover(); err != nil {
 		logger.Errorf("----recovered panic %v\n%s", err, string(debug.Stack()))
@@ -71,5 +162,8 @@ func sleepCronTime() {
 	normal := time.Duration((float64(conf.TransCronInterval) - rand.Float64()) * float64(time.Second))
 	interval := dtmimp.If(CronForwardDuration > 0, 1*time.Millisecond, normal).(time.Duration)
 	logger.Debugf("sleeping for %v milli", interval/time.Microsecond)
-	time.Sleep(interval)
+	select {
+	case <-cronWakeups.wakeup:
+	case <-time.After(interval):
+	}
 }
@@ -0,0 +1,78 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package dtmsvr
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dtm-labs/dtm/dtmcli/logger"
+	"github.com/dtm-labs/dtm/dtmsvr/storage"
+	"github.com/lithammer/shortuuid/v3"
+)
+
+// cronInstanceID identifies this dtm process among the instances sharing the cron
+var cronInstanceID = fmt.Sprintf("%s-%d-%s", hostname(), os.Getpid(), shortuuid.New())
+
+var ownedShards atomic.Value // storage.ShardFilter
+
+var startShardingOnce sync.Once
+
+func hostname() string {
+	name, _ := os.Hostname()
+	return name
+}
+
+// currentShards returns the shards owned by this instance. sharding is disabled if conf.CronShards is 0
+func currentShards() storage.ShardFilter {
+	if conf.CronShards <= 0 {
+		return storage.ShardFilter{}
+	}
+	startShardingOnce.Do(func() {
+		refreshShards()
+		go func() {
+			for {
+				time.Sleep(time.Duration(conf.CronLeaseInterval) * time.Second / 3)
+				refreshShards()
+			}
+		}()
+	})
+	shards, _ := ownedShards.Load().(storage.ShardFilter)
+	return shards
+}
+
+// refreshShards keeps this instance alive, and rebalances the shards when instances join or leave.
+// a gid may be processed by two instances during rebalancing, which is prevented by the leases
+func refreshShards() {
+	defer handlePanic(nil)
+	GetStore().KeepAliveInstance(cronInstanceID, time.Duration(conf.CronLeaseInterval)*time.Second)
+	instances := GetStore().ListInstances()
+	shards := storage.ShardFilter{Total: conf.CronShards, Owned: assignShards(instances, cronInstanceID, conf.CronShards)}
+	old, _ := ownedShards.Load().(storage.ShardFilter)
+	if fmt.Sprint(old.Owned) != fmt.Sprint(shards.Owned) {
+		logger.Infof("cron shards of instance %s changed to %v, instances: %v", cronInstanceID, shards.Owned, instances)
+	}
+	ownedShards.Store(shards)
+}
+
+// assignShards assigns shards to the sorted instances in round robin, and returns the shards of self
+func assignShards(instances []string, self string, total int64) []int64 {
+	sort.Strings(instances)
+	pos := sort.SearchStrings(instances, self)
+	if pos == len(instances) || instances[pos] != self {
+		return []int64{}
+	}
+	owned := []int64{}
+	for shard := int64(pos); shard < total; shard += int64(len(instances)) {
+		owned = append(owned, shard)
+	}
+	return owned
+}
@@ -0,0 +1,22 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package dtmsvr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssignShards(t *testing.T) {
+	instances := []string{"c", "a", "b"}
+	assert.Equal(t, []int64{0, 3, 6}, assignShards(instances, "a", 8))
+	assert.Equal(t, []int64{1, 4, 7}, assignShards(instances, "b", 8))
+	assert.Equal(t, []int64{2, 5}, assignShards(instances, "c", 8))
+	assert.Equal(t, []int64{}, assignShards(instances, "d", 8))
+	assert.Equal(t, []int64{}, assignShards(instances, "c", 2))
+}
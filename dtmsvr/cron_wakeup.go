@@ -0,0 +1,130 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package dtmsvr
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+
+	"github.com/dtm-labs/dtm/dtmcli"
+	"github.com/dtm-labs/dtm/dtmsvr/storage"
+)
+
+// wakeupQueue is a timer wheel with slots of one second. the cron is woken up when a slot with trans expires,
+// so delayed and retried trans are processed in time without polling the store.
+// the store is still scanned every TransCronInterval to recover the trans touched by other dtm processes
+type wakeupQueue struct {
+	mu     sync.Mutex
+	slots  map[int64]map[string]bool // unix second => gids
+	gids   map[string]int64          // gid => unix second
+	heap   slotHeap
+	timer  *time.Timer
+	wakeup chan struct{}
+}
+
+var cronWakeups = newWakeupQueue()
+
+func newWakeupQueue() *wakeupQueue {
+	return &wakeupQueue{
+		slots:  map[int64]map[string]bool{},
+		gids:   map[string]int64{},
+		wakeup: make(chan struct{}, 1),
+	}
+}
+
+// scheduleWakeup schedules a wakeup of the cron at the NextCronTime of an unfinished trans
+func scheduleWakeup(global *storage.TransGlobalStore) {
+	if global.NextCronTime == nil || global.Status == dtmcli.StatusSucceed || global.Status == dtmcli.StatusFailed {
+		cronWakeups.cancel(global.Gid)
+		return
+	}
+	cronWakeups.schedule(global.Gid, *global.NextCronTime)
+}
+
+// schedule wakes up the cron after at. the slot is rounded up, and one more second is added,
+// because the next_cron_time is saved in seconds, and only the trans with next_cron_time < now will be locked
+func (q *wakeupQueue) schedule(gid string, at time.Time) {
+	slot := at.Add(time.Second-time.Nanosecond).Unix() + 1
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.removeLocked(gid)
+	if q.slots[slot] == nil {
+		q.slots[slot] = map[string]bool{}
+		heap.Push(&q.heap, slot)
+	}
+	q.slots[slot][gid] = true
+	q.gids[gid] = slot
+	q.resetTimerLocked()
+}
+
+// cancel removes the wakeup of a finished trans
+func (q *wakeupQueue) cancel(gid string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.removeLocked(gid)
+}
+
+func (q *wakeupQueue) removeLocked(gid string) {
+	slot, ok := q.gids[gid]
+	if !ok {
+		return
+	}
+	delete(q.gids, gid)
+	delete(q.slots[slot], gid)
+	if len(q.slots[slot]) == 0 { // the slot is left in heap, and skipped when expired
+		delete(q.slots, slot)
+	}
+}
+
+func (q *wakeupQueue) resetTimerLocked() {
+	if len(q.heap) == 0 {
+		return
+	}
+	d := time.Until(time.Unix(q.heap[0], 0))
+	if q.timer == nil {
+		q.timer = time.AfterFunc(d, q.expire)
+	} else {
+		q.timer.Reset(d)
+	}
+}
+
+// expire pops the expired slots, and wakes up the cron if any trans is in them
+func (q *wakeupQueue) expire() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := time.Now().Unix()
+	woken := false
+	for len(q.heap) > 0 && q.heap[0] <= now {
+		slot := heap.Pop(&q.heap).(int64)
+		for gid := range q.slots[slot] {
+			delete(q.gids, gid)
+			woken = true
+		}
+		delete(q.slots, slot)
+	}
+	if woken {
+		select {
+		case q.wakeup <- struct{}{}:
+		default: // the cron has been woken up
+		}
+	}
+	q.resetTimerLocked()
+}
+
+type slotHeap []int64
+
+func (h slotHeap) Len() int            { return len(h) }
+func (h slotHeap) Less(i, j int) bool  { return h[i] < h[j] }
+func (h slotHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *slotHeap) Push(x interface{}) { *h = append(*h, x.(int64)) }
+func (h *slotHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
@@ -0,0 +1,70 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package dtmsvr
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dtm-labs/dtm/dtmcli"
+	"github.com/dtm-labs/dtm/dtmsvr/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWakeupQueue(t *testing.T) {
+	q := newWakeupQueue()
+	q.schedule("gid1", time.Now())
+	q.schedule("gid2", time.Now().Add(time.Hour))
+	q.mu.Lock()
+	assert.Equal(t, 2, len(q.slots))
+	q.mu.Unlock()
+	select {
+	case <-q.wakeup:
+	case <-time.After(3 * time.Second):
+		assert.Fail(t, "not woken up")
+	}
+	q.mu.Lock()
+	assert.Equal(t, []string{"gid2"}, keys(q.gids))
+	q.mu.Unlock()
+
+	q.cancel("gid2")
+	assert.Empty(t, q.gids)
+	assert.Empty(t, q.slots)
+}
+
+func TestWakeupQueueCancel(t *testing.T) {
+	q := newWakeupQueue()
+	q.schedule("gid1", time.Now())
+	q.cancel("gid1")
+	q.expire()
+	q.timer.Stop()
+	select {
+	case <-q.wakeup:
+		assert.Fail(t, "woken up by canceled trans")
+	default:
+	}
+}
+
+func TestScheduleWakeup(t *testing.T) {
+	next := time.Now().Add(time.Hour)
+	scheduleWakeup(&storage.TransGlobalStore{Gid: "gid-wakeup", Status: dtmcli.StatusSubmitted, NextCronTime: &next})
+	cronWakeups.mu.Lock()
+	assert.Contains(t, cronWakeups.gids, "gid-wakeup")
+	cronWakeups.mu.Unlock()
+	scheduleWakeup(&storage.TransGlobalStore{Gid: "gid-wakeup", Status: dtmcli.StatusSucceed, NextCronTime: &next})
+	cronWakeups.mu.Lock()
+	assert.NotContains(t, cronWakeups.gids, "gid-wakeup")
+	cronWakeups.mu.Unlock()
+}
+
+func keys(m map[string]int64) []string {
+	r := []string{}
+	for k := range m {
+		r = append(r, k)
+	}
+	return r
+}
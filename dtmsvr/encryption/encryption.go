@@ -0,0 +1,255 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dtm-labs/dtm/dtmcli/dtmimp"
+	"github.com/dtm-labs/dtm/dtmcli/logger"
+	"github.com/dtm-labs/dtm/dtmsvr/config"
+	"github.com/dtm-labs/dtm/dtmsvr/storage/registry"
+)
+
+// KeyProvider wraps the data keys with the master keys, like the Encrypt/Decrypt api of a KMS.
+// the master keys never leave the provider, and the wrapped data keys are saved along with the encrypted data
+type KeyProvider interface {
+	// WrapKey encrypts dataKey with the current master key, and returns the id of the master key
+	WrapKey(ctx context.Context, dataKey []byte) (keyID string, wrapped []byte, err error)
+	// UnwrapKey decrypts the data key wrapped by the master key of keyID
+	UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+// ProviderFactory creates a KeyProvider from the config
+type ProviderFactory func(conf *config.Encryption) (KeyProvider, error)
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]ProviderFactory{
+		"local": func(conf *config.Encryption) (KeyProvider, error) {
+			return NewLocalProvider(conf.KeyFile)
+		},
+	}
+)
+
+// RegisterProvider makes a key provider available by the name in config.Encryption.Provider.
+// a KMS provider calls it in the init function of its package.
+// it panics if RegisterProvider is called twice with the same name or if factory is nil
+func RegisterProvider(name string, factory ProviderFactory) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	dtmimp.PanicIf(factory == nil, fmt.Errorf("key provider factory of %s is nil", name))
+	_, dup := providers[name]
+	dtmimp.PanicIf(dup, fmt.Errorf("key provider %s registered twice", name))
+	providers[name] = factory
+}
+
+// Providers returns the sorted names of the registered key providers
+func Providers() []string {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	names := []string{}
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func getProviderFactory(name string) ProviderFactory {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	return providers[name]
+}
+
+// magic is the prefix of the encrypted data, to tell it from the data saved before encryption is enabled
+var magic = []byte("\x00dtmenc\x01")
+
+const dataKeySize = 32
+
+type dataKey struct {
+	keyID    string
+	wrapped  []byte
+	aead     cipher.AEAD
+	expireAt time.Time
+}
+
+// Encryptor encrypts data with envelope encryption: data is encrypted by a random data key with AES-256-GCM,
+// and the data key is wrapped by the master key of KeyProvider.
+// a data key is used for lifetime, so the provider is called once for many data
+type Encryptor struct {
+	provider  KeyProvider
+	lifetime  time.Duration
+	mu        sync.Mutex
+	current   *dataKey
+	unwrapped sync.Map // keyID and wrapped data key => cipher.AEAD
+}
+
+// NewEncryptor returns an Encryptor, which generates a new data key every lifetime
+func NewEncryptor(provider KeyProvider, lifetime time.Duration) *Encryptor {
+	return &Encryptor{provider: provider, lifetime: lifetime}
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (e *Encryptor) currentKey(ctx context.Context) (*dataKey, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.current != nil && time.Now().Before(e.current.expireAt) {
+		return e.current, nil
+	}
+	key := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	keyID, wrapped, err := e.provider.WrapKey(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("wrap data key failed: %w", err)
+	}
+	// the lengths are saved as a byte and a uint16 in the encrypted data
+	if len(keyID) > math.MaxUint8 || len(wrapped) > math.MaxUint16 {
+		return nil, fmt.Errorf("master key id longer than %d bytes or wrapped key longer than %d bytes: %s", math.MaxUint8, math.MaxUint16, keyID)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	e.current = &dataKey{keyID: keyID, wrapped: wrapped, aead: aead, expireAt: time.Now().Add(e.lifetime)}
+	logger.Infof("new data key generated, wrapped by master key: %s", keyID)
+	return e.current, nil
+}
+
+// Encrypt encrypts plain. empty data is not encrypted
+func (e *Encryptor) Encrypt(ctx context.Context, plain []byte) ([]byte, error) {
+	if len(plain) == 0 {
+		return plain, nil
+	}
+	key, err := e.currentKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, key.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	// magic | len of key id | key id | len of wrapped key | wrapped key | nonce | sealed data
+	buf := bytes.NewBuffer(make([]byte, 0, len(magic)+1+len(key.keyID)+2+len(key.wrapped)+len(nonce)+len(plain)+key.aead.Overhead()))
+	buf.Write(magic)
+	buf.WriteByte(byte(len(key.keyID)))
+	buf.WriteString(key.keyID)
+	_ = binary.Write(buf, binary.BigEndian, uint16(len(key.wrapped)))
+	buf.Write(key.wrapped)
+	buf.Write(nonce)
+	return key.aead.Seal(buf.Bytes(), nonce, plain, nil), nil
+}
+
+var errMalformed = errors.New("malformed encrypted data")
+
+// Decrypt decrypts data returned by Encrypt. data not encrypted is returned as is
+func (e *Encryptor) Decrypt(ctx context.Context, data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+	rest := data[len(magic):]
+	if len(rest) < 1 || len(rest) < 1+int(rest[0])+2 {
+		return nil, errMalformed
+	}
+	keyID := string(rest[1 : 1+rest[0]])
+	rest = rest[1+rest[0]:]
+	n := int(binary.BigEndian.Uint16(rest))
+	if len(rest) < 2+n {
+		return nil, errMalformed
+	}
+	wrapped := rest[2 : 2+n]
+	aead, err := e.unwrap(ctx, keyID, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	rest = rest[2+n:]
+	if len(rest) < aead.NonceSize() {
+		return nil, errMalformed
+	}
+	return aead.Open(nil, rest[:aead.NonceSize()], rest[aead.NonceSize():], nil)
+}
+
+func (e *Encryptor) unwrap(ctx context.Context, keyID string, wrapped []byte) (cipher.AEAD, error) {
+	cacheKey := keyID + "/" + string(wrapped)
+	if aead, ok := e.unwrapped.Load(cacheKey); ok {
+		return aead.(cipher.AEAD), nil
+	}
+	key, err := e.provider.UnwrapKey(ctx, keyID, wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("unwrap data key by master key %s failed: %w", keyID, err)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	e.unwrapped.Store(cacheKey, aead)
+	return aead, nil
+}
+
+// IsEncrypted checks whether data is returned by Encrypt
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, magic)
+}
+
+var (
+	defaultEncryptor *Encryptor
+	defaultErr       error
+	defaultOnce      sync.Once
+)
+
+// Default returns the Encryptor of config.Config.Encryption, or nil if encryption is disabled.
+// the go plugins in config.Store.Plugins are loaded if the provider is not registered
+func Default() (*Encryptor, error) {
+	defaultOnce.Do(func() {
+		conf := &config.Config.Encryption
+		if conf.Provider == "" {
+			return
+		}
+		factory := getProviderFactory(conf.Provider)
+		if factory == nil && config.Config.Store.Plugins != "" {
+			for _, path := range strings.Split(config.Config.Store.Plugins, ",") {
+				if defaultErr = registry.LoadPlugin(strings.TrimSpace(path)); defaultErr != nil {
+					return
+				}
+			}
+			factory = getProviderFactory(conf.Provider)
+		}
+		if factory == nil {
+			defaultErr = fmt.Errorf("key provider %s not registered, registered providers are: %s", conf.Provider, strings.Join(Providers(), ","))
+			return
+		}
+		provider, err := factory(conf)
+		if err != nil {
+			defaultErr = err
+			return
+		}
+		defaultEncryptor = NewEncryptor(provider, time.Duration(conf.DataKeyLifetime)*time.Second)
+	})
+	return defaultEncryptor, defaultErr
+}
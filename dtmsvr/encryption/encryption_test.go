@@ -0,0 +1,137 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dtm-labs/dtm/dtmcli/dtmimp"
+	"github.com/dtm-labs/dtm/dtmsvr/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func newKeyLine(id string) string {
+	key := make([]byte, dataKeySize)
+	_, _ = rand.Read(key)
+	return fmt.Sprintf("%s:%s\n", id, base64.StdEncoding.EncodeToString(key))
+}
+
+func writeKeyFile(t *testing.T, path string, lines ...string) {
+	assert.Nil(t, ioutil.WriteFile(path, []byte(strings.Join(lines, "")), 0600))
+	// the file is reloaded only if modified, so the mod time is changed explicitly
+	next := time.Now().Add(time.Duration(len(lines)) * time.Second)
+	assert.Nil(t, os.Chtimes(path, next, next))
+}
+
+func TestEncryptor(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "keys")
+	writeKeyFile(t, path, "# comment\n", newKeyLine("k1"))
+	p, err := NewLocalProvider(path)
+	assert.Nil(t, err)
+	e := NewEncryptor(p, time.Hour)
+
+	plain := []byte(`{"card":"4111111111111111"}`)
+	data, err := e.Encrypt(ctx, plain)
+	assert.Nil(t, err)
+	assert.True(t, IsEncrypted(data))
+	assert.False(t, bytes.Contains(data, plain))
+	decrypted, err := e.Decrypt(ctx, data)
+	assert.Nil(t, err)
+	assert.Equal(t, plain, decrypted)
+
+	empty, err := e.Encrypt(ctx, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, empty)
+	decrypted, err = e.Decrypt(ctx, plain) // saved before encryption is enabled
+	assert.Nil(t, err)
+	assert.Equal(t, plain, decrypted)
+
+	data[len(data)-1] ^= 1
+	_, err = e.Decrypt(ctx, data)
+	assert.Error(t, err)
+	_, err = e.Decrypt(ctx, data[:len(magic)+3])
+	assert.Error(t, err)
+}
+
+func TestKeyRotation(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "keys")
+	k1 := newKeyLine("k1")
+	writeKeyFile(t, path, k1)
+	p, err := NewLocalProvider(path)
+	assert.Nil(t, err)
+	e := NewEncryptor(p, 0) // a new data key for every encryption
+
+	data1, err := e.Encrypt(ctx, []byte("data1"))
+	assert.Nil(t, err)
+	writeKeyFile(t, path, newKeyLine("k2"), k1)
+	data2, err := e.Encrypt(ctx, []byte("data2"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(data2), "k2")
+
+	// a new encryptor has no cached data keys, and unwraps them by the provider
+	e = NewEncryptor(p, 0)
+	decrypted, err := e.Decrypt(ctx, data1)
+	assert.Nil(t, err)
+	assert.Equal(t, "data1", string(decrypted))
+	decrypted, err = e.Decrypt(ctx, data2)
+	assert.Nil(t, err)
+	assert.Equal(t, "data2", string(decrypted))
+
+	// the removed keys are dropped when the file is reloaded to wrap a new data key
+	writeKeyFile(t, path, newKeyLine("k3"))
+	e = NewEncryptor(p, 0)
+	_, err = e.Encrypt(ctx, []byte("data3"))
+	assert.Nil(t, err)
+	_, err = e.Decrypt(ctx, data1)
+	assert.Contains(t, err.Error(), "master key k1 not found")
+}
+
+func TestLongKeyID(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys")
+	writeKeyFile(t, path, newKeyLine(strings.Repeat("k", 256)))
+	p, err := NewLocalProvider(path)
+	assert.Nil(t, err)
+	_, err = NewEncryptor(p, time.Hour).Encrypt(context.Background(), []byte("data"))
+	assert.Error(t, err)
+}
+
+func TestLocalProviderErrors(t *testing.T) {
+	dir := t.TempDir()
+	_, err := NewLocalProvider(filepath.Join(dir, "not-exists"))
+	assert.Error(t, err)
+	for _, cont := range []string{"", "k1\n", "k1:bad-base64\n", "k1:" + base64.StdEncoding.EncodeToString([]byte("short")) + "\n"} {
+		path := filepath.Join(dir, "keys")
+		assert.Nil(t, ioutil.WriteFile(path, []byte(cont), 0600))
+		_, err := NewLocalProvider(path)
+		assert.Error(t, err, cont)
+	}
+}
+
+func TestRegisterProvider(t *testing.T) {
+	RegisterProvider("test-provider", func(conf *config.Encryption) (KeyProvider, error) {
+		return nil, nil
+	})
+	assert.Equal(t, []string{"local", "test-provider"}, Providers())
+	assert.Error(t, dtmimp.CatchP(func() {
+		RegisterProvider("test-provider", func(conf *config.Encryption) (KeyProvider, error) { return nil, nil })
+	}))
+	assert.Error(t, dtmimp.CatchP(func() {
+		RegisterProvider("test-nil", nil)
+	}))
+}
@@ -0,0 +1,134 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package encryption
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dtm-labs/dtm/dtmcli/logger"
+)
+
+// LocalProvider is a KeyProvider with the master keys in a local file.
+// each line of the file is 'id:base64 of a 32 bytes key', and lines starting with # are comments.
+// the key of the first line is used to wrap new data keys, and all keys are used to unwrap.
+// to rotate the master key, add a new key as the first line, and keep the old keys until the data encrypted by them expire.
+// the file is reloaded when it is modified
+type LocalProvider struct {
+	path    string
+	mu      sync.Mutex
+	modTime time.Time
+	current string
+	keys    map[string][]byte
+}
+
+// NewLocalProvider returns a LocalProvider with the keys in the file of path
+func NewLocalProvider(path string) (*LocalProvider, error) {
+	p := &LocalProvider{path: path}
+	return p, p.mayReload()
+}
+
+func (p *LocalProvider) mayReload() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	st, err := os.Stat(p.path)
+	if err != nil {
+		return err
+	}
+	if st.ModTime().Equal(p.modTime) {
+		return nil
+	}
+	cont, err := ioutil.ReadFile(p.path)
+	if err != nil {
+		return err
+	}
+	current, keys := "", map[string][]byte{}
+	scanner := bufio.NewScanner(bytes.NewReader(cont))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		kv := strings.SplitN(line, ":", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return fmt.Errorf("bad line in key file %s, should be 'id:base64 of key'", p.path)
+		}
+		key, err := base64.StdEncoding.DecodeString(kv[1])
+		if err != nil || len(key) != dataKeySize {
+			return fmt.Errorf("key %s in key file %s should be base64 of %d bytes", kv[0], p.path, dataKeySize)
+		}
+		if current == "" {
+			current = kv[0]
+		}
+		keys[kv[0]] = key
+	}
+	if current == "" {
+		return fmt.Errorf("no key found in key file %s", p.path)
+	}
+	logger.Infof("key file %s loaded, current master key: %s", p.path, current)
+	p.modTime, p.current, p.keys = st.ModTime(), current, keys
+	return nil
+}
+
+func (p *LocalProvider) getKey(keyID string) (string, []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if keyID == "" {
+		keyID = p.current
+	}
+	return keyID, p.keys[keyID]
+}
+
+// WrapKey encrypts dataKey with the key of the first line
+func (p *LocalProvider) WrapKey(ctx context.Context, dataKey []byte) (string, []byte, error) {
+	if err := p.mayReload(); err != nil {
+		return "", nil, err
+	}
+	keyID, key := p.getKey("")
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", nil, err
+	}
+	return keyID, aead.Seal(nonce, nonce, dataKey, []byte(keyID)), nil
+}
+
+// UnwrapKey decrypts the data key wrapped by the key of keyID
+func (p *LocalProvider) UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	_, key := p.getKey(keyID)
+	if key == nil {
+		if err := p.mayReload(); err != nil {
+			return nil, err
+		}
+		_, key = p.getKey(keyID)
+	}
+	if key == nil {
+		return nil, fmt.Errorf("master key %s not found in key file %s", keyID, p.path)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(wrapped) < aead.NonceSize() {
+		return nil, errors.New("malformed wrapped key")
+	}
+	return aead.Open(nil, wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():], []byte(keyID))
+}
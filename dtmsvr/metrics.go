@@ -53,6 +53,27 @@ var (
 		Help: "All branches processed by dtm",
 	},
 		[]string{"model", "gid", "branchid", "branchtype", "status"})
+
+	cronLockedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "dtm_cron_locked_total",
+		Help: "All transactions locked by the cron of dtm",
+	})
+
+	cronWorkerBusy = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "dtm_cron_worker_busy",
+		Help: "The number of cron workers processing transactions",
+	})
+
+	archivedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "dtm_archived_transaction_total",
+		Help: "All finished transactions archived by dtm",
+	},
+		[]string{"target"})
+
+	archiveFailedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "dtm_archive_failed_total",
+		Help: "All failed batches of archiving finished transactions",
+	})
 )
 
 func setServerInfoMetrics() {
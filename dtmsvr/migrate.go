@@ -0,0 +1,154 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package dtmsvr
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/dtm-labs/dtm/dtmcli"
+	"github.com/dtm-labs/dtm/dtmcli/dtmimp"
+	"github.com/dtm-labs/dtm/dtmcli/logger"
+	"github.com/dtm-labs/dtm/dtmsvr/config"
+	"github.com/dtm-labs/dtm/dtmsvr/storage"
+)
+
+// MigrateOptions defines the options of Migrate
+type MigrateOptions struct {
+	DryRun    bool   // only scan the trans in the source store
+	Verify    bool   // compare the trans in the source store with the destination store, instead of migrating
+	StateFile string // the scan position is saved in this file after every batch, so that the migration can resume from it
+	Resume    bool   // resume from the position saved in StateFile
+	BatchSize int64
+}
+
+// MigrateStats defines the result of Migrate
+type MigrateStats struct {
+	Scanned    int64 // trans scanned in the source store
+	Migrated   int64 // trans saved to the destination store
+	Existed    int64 // trans existed in the destination store, they are skipped
+	Mismatched int64 // trans different in the destination store, only for Verify
+}
+
+// Migrate copies all the trans from the store of config from to the store of config to,
+// with the status, cron time and payloads preserved. dtm servers should be stopped while migrating.
+// the stores read the global config in every call, so the config is switched before every call
+func Migrate(from config.Store, to config.Store, opts MigrateOptions) (stats MigrateStats, rerr error) {
+	defer dtmimp.P2E(&rerr)
+	if from.Driver == to.Driver && !from.IsDB() {
+		return stats, fmt.Errorf("migrating between two %s stores is not supported", from.Driver)
+	}
+	old := conf.Store
+	defer func() { conf.Store = old }()
+	position := ""
+	if opts.Resume {
+		if cont, err := ioutil.ReadFile(opts.StateFile); err == nil {
+			position = string(cont)
+			logger.Infof("migration resumes from position: %s", position)
+		} else if !os.IsNotExist(err) {
+			return stats, err
+		}
+	}
+	for {
+		globals := useStore(from).ScanTransGlobalStores(&position, opts.BatchSize)
+		for i := range globals {
+			stats.Scanned++
+			global := &globals[i]
+			branches := useStore(from).FindBranches(global.Gid)
+			if opts.Verify {
+				if diff := diffTrans(global, branches, to); diff != "" {
+					logger.Errorf("gid %s mismatched: %s", global.Gid, diff)
+					stats.Mismatched++
+				}
+			} else if !opts.DryRun {
+				err := migrateTrans(global, branches, to)
+				if errors.Is(err, storage.ErrUniqueConflict) {
+					stats.Existed++
+				} else if err != nil {
+					return stats, err
+				} else {
+					stats.Migrated++
+				}
+			}
+		}
+		if opts.StateFile != "" && !opts.DryRun && !opts.Verify {
+			if err := ioutil.WriteFile(opts.StateFile, []byte(position), 0644); err != nil {
+				return stats, err
+			}
+		}
+		logger.Infof("migration stats: %+v, position: '%s'", stats, position)
+		if position == "" {
+			break
+		}
+	}
+	if opts.StateFile != "" && !opts.DryRun && !opts.Verify {
+		return stats, os.Remove(opts.StateFile)
+	}
+	return stats, nil
+}
+
+// useStore switches the global store config to c, and returns the store of c
+func useStore(c config.Store) storage.Store {
+	conf.Store = c
+	return GetStore()
+}
+
+// migrateTrans saves the trans to the store of config to. the ids of the source store are cleared.
+// a finished trans is finished again in the stores except db, so that it is removed from the cron index, and expires in FinishedDataExpire
+func migrateTrans(global *storage.TransGlobalStore, branches []storage.TransBranchStore, to config.Store) error {
+	global.ID = 0
+	for i := range branches {
+		branches[i].ID = 0
+	}
+	status := global.Status
+	s := useStore(to)
+	err := s.MaySaveNewTrans(global, branches)
+	if err == nil && (status == dtmcli.StatusSucceed || status == dtmcli.StatusFailed) && !to.IsDB() {
+		err = dtmimp.CatchP(func() {
+			s.ChangeGlobalStatus(global, status, []string{"status"}, true)
+		})
+	}
+	return err
+}
+
+// diffTrans returns the first difference of the trans with the one in the store of config to
+func diffTrans(global *storage.TransGlobalStore, branches []storage.TransBranchStore, to config.Store) string {
+	s := useStore(to)
+	saved := s.FindTransGlobalStore(global.Gid)
+	if saved == nil {
+		return "not found"
+	}
+	cronTime := func(g *storage.TransGlobalStore) int64 {
+		if g.NextCronTime == nil {
+			return 0
+		}
+		return g.NextCronTime.Unix()
+	}
+	diffs := [][3]interface{}{
+		{"status", global.Status, saved.Status},
+		{"trans_type", global.TransType, saved.TransType},
+		{"protocol", global.Protocol, saved.Protocol},
+		{"next_cron_time", cronTime(global), cronTime(saved)},
+		{"next_cron_interval", global.NextCronInterval, saved.NextCronInterval},
+		{"custom_data", global.CustomData, saved.CustomData},
+	}
+	savedBranches := s.FindBranches(global.Gid)
+	diffs = append(diffs, [3]interface{}{"branches", len(branches), len(savedBranches)})
+	for i := 0; i < len(branches) && i < len(savedBranches); i++ {
+		b, sb := branches[i], savedBranches[i]
+		diffs = append(diffs, [3]interface{}{"branch " + b.BranchID + " " + b.Op, b.BranchID + b.Op + b.Status + b.URL, sb.BranchID + sb.Op + sb.Status + sb.URL})
+		diffs = append(diffs, [3]interface{}{"payload of " + b.BranchID + " " + b.Op, string(b.BinData), string(sb.BinData)})
+	}
+	for _, d := range diffs {
+		if d[1] != d[2] {
+			return fmt.Sprintf("%s: '%v' != '%v'", d[0], d[1], d[2])
+		}
+	}
+	return ""
+}
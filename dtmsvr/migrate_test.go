@@ -0,0 +1,71 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package dtmsvr
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dtm-labs/dtm/dtmcli"
+	"github.com/dtm-labs/dtm/dtmcli/dtmimp"
+	"github.com/dtm-labs/dtm/dtmsvr/config"
+	"github.com/dtm-labs/dtm/dtmsvr/storage"
+	"github.com/dtm-labs/dtm/dtmutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func newSqliteStore(dir string) config.Store {
+	s := config.Store{Driver: config.Sqlite, Host: dir, TransGlobalTable: "dtm.trans_global", TransBranchOpTable: "dtm.trans_branch_op"}
+	dtmutil.RunSQLScript(s.GetDBConf(), "../sqls/dtmsvr.storage.sqlite.sql", false)
+	return s
+}
+
+func TestMigrate(t *testing.T) {
+	dir := t.TempDir()
+	from := newSqliteStore(filepath.Join(dir, "from"))
+	to := newSqliteStore(filepath.Join(dir, "to"))
+	now := time.Now()
+	for _, gid := range []string{"migrate1", "migrate2", "migrate3"} {
+		g := &storage.TransGlobalStore{Gid: gid, Status: dtmcli.StatusPrepared, TransType: "saga", Protocol: "http",
+			NextCronInterval: 10, NextCronTime: &now, CustomData: "{}"}
+		branches := []storage.TransBranchStore{
+			{Gid: gid, BranchID: "01", Op: dtmimp.OpAction, Status: dtmcli.StatusPrepared, URL: "http://busi/action", BinData: []byte(`{"a":1}`)},
+		}
+		assert.Nil(t, useStore(from).MaySaveNewTrans(g, branches))
+	}
+	opts := MigrateOptions{StateFile: filepath.Join(dir, "state"), BatchSize: 2}
+
+	stats, err := Migrate(from, to, MigrateOptions{DryRun: true, BatchSize: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, MigrateStats{Scanned: 3}, stats)
+
+	// resume from the position after the first trans, which is scanned in the order of id desc
+	assert.Nil(t, ioutil.WriteFile(opts.StateFile, []byte("3"), 0644))
+	stats, err = Migrate(from, to, MigrateOptions{StateFile: opts.StateFile, Resume: true, BatchSize: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, MigrateStats{Scanned: 2, Migrated: 2}, stats)
+	assert.NoFileExists(t, opts.StateFile)
+
+	stats, err = Migrate(from, to, opts)
+	assert.Nil(t, err)
+	assert.Equal(t, MigrateStats{Scanned: 3, Migrated: 1, Existed: 2}, stats)
+
+	stats, err = Migrate(from, to, MigrateOptions{Verify: true, BatchSize: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, MigrateStats{Scanned: 3}, stats)
+
+	g := useStore(to).FindTransGlobalStore("migrate2")
+	useStore(to).ChangeGlobalStatus(g, dtmcli.StatusSucceed, []string{"status"}, true)
+	stats, err = Migrate(from, to, MigrateOptions{Verify: true, BatchSize: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, MigrateStats{Scanned: 3, Mismatched: 1}, stats)
+
+	_, err = Migrate(config.Store{Driver: config.Redis}, config.Store{Driver: config.Redis}, opts)
+	assert.Error(t, err)
+}
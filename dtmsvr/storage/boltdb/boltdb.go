@@ -7,8 +7,12 @@
 package boltdb
 
 import (
+	"context"
 	"fmt"
+	"hash/crc32"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/dtm-labs/dtm/dtmcli"
@@ -16,23 +20,25 @@ import (
 	"github.com/dtm-labs/dtm/dtmcli/logger"
 	"github.com/dtm-labs/dtm/dtmsvr/storage"
 	"github.com/dtm-labs/dtm/dtmutil"
+	"github.com/lithammer/shortuuid/v3"
 	bolt "go.etcd.io/bbolt"
 )
 
-// Store implements storage.Store, and storage with boltdb
+// Store implements storage.StoreV2, and storage with boltdb
 type Store struct {
 	boltDb *bolt.DB
 
 	dataExpire    int64
-	retryInterval int64
+	leaseInterval int64
+	instances     sync.Map // instance => expire time
 }
 
 // NewStore will return the boltdb implement
 // TODO: change to options
-func NewStore(dataExpire int64, retryInterval int64) *Store {
+func NewStore(dataExpire int64, leaseInterval int64) *Store {
 	s := &Store{
 		dataExpire:    dataExpire,
-		retryInterval: retryInterval,
+		leaseInterval: leaseInterval,
 	}
 
 	db, err := bolt.Open("./dtm.bolt", 0666, &bolt.Options{Timeout: 1 * time.Second})
@@ -235,48 +241,77 @@ func tPutIndex(t *bolt.Tx, unix int64, gid string) {
 	dtmimp.E2P(err)
 }
 
-// Ping execs ping cmd to boltdb
-func (s *Store) Ping() error {
-	return nil
+// update runs fn in a read-write transaction, which is rolled back if fn returns an error or ctx is done.
+// bolt has only one writer at a time, so ctx is checked after the writer lock is held. the panics of fn are returned as errors
+func (s *Store) update(ctx context.Context, fn func(t *bolt.Tx) error) error {
+	return dtmimp.CatchP(func() {
+		dtmimp.E2P(s.boltDb.Update(func(t *bolt.Tx) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			return fn(t)
+		}))
+	})
 }
 
-// PopulateData populates data to boltdb
-func (s *Store) PopulateData(skipDrop bool) {
-	if !skipDrop {
-		err := s.boltDb.Update(func(t *bolt.Tx) error {
-			dtmimp.E2P(t.DeleteBucket(bucketIndex))
-			dtmimp.E2P(t.DeleteBucket(bucketBranches))
-			dtmimp.E2P(t.DeleteBucket(bucketGlobal))
-			_, err := t.CreateBucket(bucketIndex)
-			dtmimp.E2P(err)
-			_, err = t.CreateBucket(bucketBranches)
-			dtmimp.E2P(err)
-			_, err = t.CreateBucket(bucketGlobal)
-			dtmimp.E2P(err)
+// view runs fn in a read-only transaction if ctx is not done. the panics of fn are returned as errors
+func (s *Store) view(ctx context.Context, fn func(t *bolt.Tx) error) error {
+	return dtmimp.CatchP(func() {
+		dtmimp.E2P(s.boltDb.View(func(t *bolt.Tx) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			return fn(t)
+		}))
+	})
+}
 
-			return nil
-		})
-		dtmimp.E2P(err)
+// PingContext execs ping cmd to boltdb
+func (s *Store) PingContext(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// PopulateDataContext populates data to boltdb
+func (s *Store) PopulateDataContext(ctx context.Context, skipDrop bool) error {
+	if skipDrop {
+		return nil
+	}
+	err := s.update(ctx, func(t *bolt.Tx) error {
+		for _, bucket := range allBuckets {
+			dtmimp.E2P(t.DeleteBucket(bucket))
+			_, err := t.CreateBucket(bucket)
+			dtmimp.E2P(err)
+		}
+		return nil
+	})
+	if err == nil {
 		logger.Infof("Reset all data for boltdb")
 	}
+	return err
 }
 
-// FindTransGlobalStore finds GlobalTrans data by gid
-func (s *Store) FindTransGlobalStore(gid string) (trans *storage.TransGlobalStore) {
-	err := s.boltDb.View(func(t *bolt.Tx) error {
+// FindTransGlobalStoreContext finds GlobalTrans data by gid
+func (s *Store) FindTransGlobalStoreContext(ctx context.Context, gid string) (trans *storage.TransGlobalStore, err error) {
+	err = s.view(ctx, func(t *bolt.Tx) error {
 		trans = tGetGlobal(t, gid)
+		if trans == nil {
+			return storage.ErrNotFound
+		}
 		return nil
 	})
-	dtmimp.E2P(err)
 	return
 }
 
-// ScanTransGlobalStores lists GlobalTrans data
-func (s *Store) ScanTransGlobalStores(position *string, limit int64) []storage.TransGlobalStore {
+// ScanTransGlobalStoresContext lists GlobalTrans data
+func (s *Store) ScanTransGlobalStoresContext(ctx context.Context, position *string, limit int64) ([]storage.TransGlobalStore, error) {
 	globals := []storage.TransGlobalStore{}
-	err := s.boltDb.View(func(t *bolt.Tx) error {
+	err := s.view(ctx, func(t *bolt.Tx) error {
 		cursor := t.Bucket(bucketGlobal).Cursor()
-		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+		k, v := cursor.First()
+		if *position != "" {
+			k, v = cursor.Seek([]byte(*position))
+		}
+		for ; k != nil; k, v = cursor.Next() {
 			if string(k) == *position {
 				continue
 			}
@@ -289,50 +324,73 @@ func (s *Store) ScanTransGlobalStores(position *string, limit int64) []storage.T
 		}
 		return nil
 	})
-	dtmimp.E2P(err)
+	if err != nil {
+		return nil, err
+	}
 	if len(globals) < int(limit) {
 		*position = ""
 	} else {
 		*position = globals[len(globals)-1].Gid
 	}
-	return globals
+	return globals, nil
 }
 
-// FindBranches finds Branch data by gid
-func (s *Store) FindBranches(gid string) []storage.TransBranchStore {
-	var branches []storage.TransBranchStore
-	err := s.boltDb.View(func(t *bolt.Tx) error {
+// FindBranchesContext finds Branch data by gid
+func (s *Store) FindBranchesContext(ctx context.Context, gid string) (branches []storage.TransBranchStore, err error) {
+	err = s.view(ctx, func(t *bolt.Tx) error {
 		branches = tGetBranches(t, gid)
 		return nil
 	})
-	dtmimp.E2P(err)
-	return branches
+	return
 }
 
-// UpdateBranches update branches info
-func (s *Store) UpdateBranches(branches []storage.TransBranchStore, updates []string) (int, error) {
-	return 0, nil // not implemented
+// UpdateBranchesContext update branches info
+func (s *Store) UpdateBranchesContext(ctx context.Context, branches []storage.TransBranchStore, updates []string) (int, error) {
+	updated := 0
+	err := s.update(ctx, func(t *bolt.Tx) error {
+		for _, gid := range storage.BranchGids(branches) {
+			saved := tGetBranches(t, gid)
+			changed, err := storage.MergeBranchUpdates(saved, branches, updates)
+			if err != nil {
+				return err
+			}
+			for _, i := range changed {
+				tPutBranches(t, saved[i:i+1], int64(i))
+			}
+			updated += len(changed)
+		}
+		return nil
+	})
+	if err != nil {
+		updated = 0
+	}
+	return updated, err
+}
+
+// ownedBy checks whether g is leased to the owner in ctx, if any
+func ownedBy(ctx context.Context, g *storage.TransGlobalStore) bool {
+	owner := storage.OwnerOf(ctx)
+	return owner == "" || g.Owner == owner
 }
 
-// LockGlobalSaveBranches creates branches
-func (s *Store) LockGlobalSaveBranches(gid string, status string, branches []storage.TransBranchStore, branchStart int) {
-	err := s.boltDb.Update(func(t *bolt.Tx) error {
+// LockGlobalSaveBranchesContext creates branches
+func (s *Store) LockGlobalSaveBranchesContext(ctx context.Context, gid string, status string, branches []storage.TransBranchStore, branchStart int) error {
+	return s.update(ctx, func(t *bolt.Tx) error {
 		g := tGetGlobal(t, gid)
 		if g == nil {
 			return storage.ErrNotFound
 		}
-		if g.Status != status {
+		if g.Status != status || !ownedBy(ctx, g) {
 			return storage.ErrNotFound
 		}
 		tPutBranches(t, branches, int64(branchStart))
 		return nil
 	})
-	dtmimp.E2P(err)
 }
 
-// MaySaveNewTrans creates a new trans
-func (s *Store) MaySaveNewTrans(global *storage.TransGlobalStore, branches []storage.TransBranchStore) error {
-	return s.boltDb.Update(func(t *bolt.Tx) error {
+// MaySaveNewTransContext creates a new trans
+func (s *Store) MaySaveNewTransContext(ctx context.Context, global *storage.TransGlobalStore, branches []storage.TransBranchStore) error {
+	return s.update(ctx, func(t *bolt.Tx) error {
 		g := tGetGlobal(t, global.Gid)
 		if g != nil {
 			return storage.ErrUniqueConflict
@@ -344,31 +402,55 @@ func (s *Store) MaySaveNewTrans(global *storage.TransGlobalStore, branches []sto
 	})
 }
 
-// ChangeGlobalStatus changes global trans status
-func (s *Store) ChangeGlobalStatus(global *storage.TransGlobalStore, newStatus string, updates []string, finished bool) {
+// MaySaveNewTransBatchContext creates many trans in one boltdb transaction
+func (s *Store) MaySaveNewTransBatchContext(ctx context.Context, globals []*storage.TransGlobalStore, branches [][]storage.TransBranchStore) []error {
+	errs := make([]error, len(globals))
+	err := s.update(ctx, func(t *bolt.Tx) error {
+		for i, global := range globals {
+			if tGetGlobal(t, global.Gid) != nil {
+				errs[i] = storage.ErrUniqueConflict
+				continue
+			}
+			tPutGlobal(t, global)
+			tPutIndex(t, global.NextCronTime.Unix(), global.Gid)
+			tPutBranches(t, branches[i], 0)
+		}
+		return nil
+	})
+	if err != nil {
+		for i := range errs {
+			errs[i] = err
+		}
+	}
+	return errs
+}
+
+// ChangeGlobalStatusContext changes global trans status
+func (s *Store) ChangeGlobalStatusContext(ctx context.Context, global *storage.TransGlobalStore, newStatus string, updates []string, finished bool) error {
 	old := global.Status
 	global.Status = newStatus
-	err := s.boltDb.Update(func(t *bolt.Tx) error {
+	return s.update(ctx, func(t *bolt.Tx) error {
 		g := tGetGlobal(t, global.Gid)
-		if g == nil || g.Status != old {
+		if g == nil || g.Status != old || !ownedBy(ctx, g) {
 			return storage.ErrNotFound
 		}
 		if finished {
 			tDelIndex(t, g.NextCronTime.Unix(), g.Gid)
 		}
-		tPutGlobal(t, global)
+		saved := *global
+		saved.NextCronTime, saved.Owner = g.NextCronTime, g.Owner // maintained by TouchCronTime and leases
+		tPutGlobal(t, &saved)
 		return nil
 	})
-	dtmimp.E2P(err)
 }
 
-// TouchCronTime updates cronTime
-func (s *Store) TouchCronTime(global *storage.TransGlobalStore, nextCronInterval int64, nextCronTime *time.Time) {
+// TouchCronTimeContext updates cronTime
+func (s *Store) TouchCronTimeContext(ctx context.Context, global *storage.TransGlobalStore, nextCronInterval int64, nextCronTime *time.Time) error {
 	oldUnix := global.NextCronTime.Unix()
 	global.UpdateTime = dtmutil.GetNextTime(0)
 	global.NextCronTime = nextCronTime
 	global.NextCronInterval = nextCronInterval
-	err := s.boltDb.Update(func(t *bolt.Tx) error {
+	return s.update(ctx, func(t *bolt.Tx) error {
 		g := tGetGlobal(t, global.Gid)
 		if g == nil || g.Gid != global.Gid {
 			return storage.ErrNotFound
@@ -378,51 +460,142 @@ func (s *Store) TouchCronTime(global *storage.TransGlobalStore, nextCronInterval
 		tPutIndex(t, global.NextCronTime.Unix(), global.Gid)
 		return nil
 	})
-	dtmimp.E2P(err)
 }
 
-// LockOneGlobalTrans finds GlobalTrans
-func (s *Store) LockOneGlobalTrans(expireIn time.Duration) *storage.TransGlobalStore {
-	var trans *storage.TransGlobalStore
+// LockOneGlobalTransContext finds GlobalTrans, and leases it to a new owner for leaseInterval
+func (s *Store) LockOneGlobalTransContext(ctx context.Context, expireIn time.Duration) (*storage.TransGlobalStore, error) {
+	globals, err := s.LockGlobalTransBatchContext(ctx, expireIn, 1, storage.ShardFilter{})
+	if err != nil || len(globals) == 0 {
+		return nil, err
+	}
+	return globals[0], nil
+}
+
+// LockGlobalTransBatchContext finds at most limit GlobalTrans in shards, and leases them to a new owner for leaseInterval
+func (s *Store) LockGlobalTransBatchContext(ctx context.Context, expireIn time.Duration, limit int64, shards storage.ShardFilter) ([]*storage.TransGlobalStore, error) {
+	globals := []*storage.TransGlobalStore{}
 	min := fmt.Sprintf("%d", time.Now().Add(expireIn).Unix())
-	next := time.Now().Add(time.Duration(s.retryInterval) * time.Second)
-	err := s.boltDb.Update(func(t *bolt.Tx) error {
+	next := time.Now().Add(time.Duration(s.leaseInterval) * time.Second)
+	owner := shortuuid.New()
+	err := s.update(ctx, func(t *bolt.Tx) error {
 		cursor := t.Bucket(bucketIndex).Cursor()
 		toDelete := [][]byte{}
-		for trans == nil || trans.Status == dtmcli.StatusSucceed || trans.Status == dtmcli.StatusFailed {
-			k, v := cursor.First()
-			if k == nil || string(k) > min {
-				return storage.ErrNotFound
+		for k, v := cursor.First(); k != nil && string(k) <= min && int64(len(globals)) < limit; k, v = cursor.Next() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if !inShards(string(v), shards) {
+				continue
 			}
-			trans = tGetGlobal(t, string(v))
 			toDelete = append(toDelete, k)
+			trans := tGetGlobal(t, string(v))
+			if trans != nil && trans.Status != dtmcli.StatusSucceed && trans.Status != dtmcli.StatusFailed {
+				globals = append(globals, trans)
+			}
 		}
 		for _, k := range toDelete {
 			err := t.Bucket(bucketIndex).Delete(k)
 			dtmimp.E2P(err)
 		}
-		trans.NextCronTime = &next
-		tPutGlobal(t, trans)
-		tPutIndex(t, next.Unix(), trans.Gid)
+		for _, trans := range globals {
+			trans.NextCronTime = &next
+			trans.Owner = owner
+			tPutGlobal(t, trans)
+			tPutIndex(t, next.Unix(), trans.Gid)
+		}
 		return nil
 	})
-	if err == storage.ErrNotFound {
-		return nil
+	if err != nil {
+		return nil, err
 	}
-	dtmimp.E2P(err)
-	return trans
+	return globals, nil
+}
+
+func inShards(gid string, shards storage.ShardFilter) bool {
+	if shards.Total == 0 {
+		return true
+	}
+	shard := int64(crc32.ChecksumIEEE([]byte(gid))) % shards.Total
+	for _, owned := range shards.Owned {
+		if owned == shard {
+			return true
+		}
+	}
+	return false
 }
 
-// ResetCronTime rest nextCronTime
+// RenewLeaseContext extends the lease of owner for another leaseInterval
+func (s *Store) RenewLeaseContext(ctx context.Context, gid string, owner string) error {
+	next := time.Now().Add(time.Duration(s.leaseInterval) * time.Second)
+	return s.update(ctx, func(t *bolt.Tx) error {
+		g := tGetGlobal(t, gid)
+		if g == nil || g.Owner != owner || g.Status == dtmcli.StatusSucceed || g.Status == dtmcli.StatusFailed {
+			return storage.ErrNotFound
+		}
+		tDelIndex(t, g.NextCronTime.Unix(), gid)
+		g.NextCronTime = &next
+		tPutGlobal(t, g)
+		tPutIndex(t, next.Unix(), gid)
+		return nil
+	})
+}
+
+// ReleaseLeaseContext releases the lease of global.Owner, and saves the next cron time of global
+func (s *Store) ReleaseLeaseContext(ctx context.Context, global *storage.TransGlobalStore) error {
+	owner := global.Owner
+	global.UpdateTime = dtmutil.GetNextTime(0)
+	global.Owner = ""
+	return s.update(ctx, func(t *bolt.Tx) error {
+		g := tGetGlobal(t, global.Gid)
+		if g == nil || g.Owner != owner || g.Status != global.Status || g.Status == dtmcli.StatusSucceed || g.Status == dtmcli.StatusFailed {
+			return nil
+		}
+		tDelIndex(t, g.NextCronTime.Unix(), g.Gid)
+		g.Owner = ""
+		g.NextCronTime = global.NextCronTime
+		g.NextCronInterval = global.NextCronInterval
+		g.UpdateTime = global.UpdateTime
+		tPutGlobal(t, g)
+		tPutIndex(t, g.NextCronTime.Unix(), g.Gid)
+		return nil
+	})
+}
+
+// KeepAliveInstanceContext registers instance as alive until expireIn later.
+// boltdb is used by a single dtm process, so instances are kept in memory
+func (s *Store) KeepAliveInstanceContext(ctx context.Context, instance string, expireIn time.Duration) error {
+	s.instances.Store(instance, time.Now().Add(expireIn))
+	return nil
+}
+
+// ListInstancesContext lists alive instances
+func (s *Store) ListInstancesContext(ctx context.Context) ([]string, error) {
+	instances := []string{}
+	s.instances.Range(func(k, v interface{}) bool {
+		if v.(time.Time).After(time.Now()) {
+			instances = append(instances, k.(string))
+		} else {
+			s.instances.Delete(k)
+		}
+		return true
+	})
+	sort.Strings(instances)
+	return instances, nil
+}
+
+// ResetCronTimeContext rest nextCronTime
 // Prevent multiple backoff from causing NextCronTime to be too long
-func (s *Store) ResetCronTime(timeout time.Duration, limit int64) (succeedCount int64, hasRemaining bool, err error) {
+func (s *Store) ResetCronTimeContext(ctx context.Context, timeout time.Duration, limit int64) (succeedCount int64, hasRemaining bool, err error) {
 	next := time.Now()
 	var trans *storage.TransGlobalStore
 	min := fmt.Sprintf("%d", time.Now().Add(timeout).Unix())
-	err = s.boltDb.Update(func(t *bolt.Tx) error {
+	err = s.update(ctx, func(t *bolt.Tx) error {
 		cursor := t.Bucket(bucketIndex).Cursor()
 		succeedCount = 0
 		for k, v := cursor.Seek([]byte(min)); k != nil && succeedCount <= limit; k, v = cursor.Next() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
 			if succeedCount == limit {
 				hasRemaining = true
 				break
@@ -7,6 +7,7 @@
 package boltdb
 
 import (
+	"context"
 	"path"
 	"testing"
 	"time"
@@ -15,7 +16,9 @@ import (
 	bolt "go.etcd.io/bbolt"
 
 	"github.com/dtm-labs/dtm/dtmcli/dtmimp"
+	"github.com/dtm-labs/dtm/dtmsvr/config"
 	"github.com/dtm-labs/dtm/dtmsvr/storage"
+	"github.com/dtm-labs/dtm/dtmsvr/storage/storetest"
 )
 
 func TestInitializeBuckets(t *testing.T) {
@@ -336,3 +339,154 @@ func TestCleanupIndexWithGids(t *testing.T) {
 		g.Expect(actualKeys).To(Equal([]string{"3-gid2", "a", "z"}))
 	})
 }
+
+func TestLease(t *testing.T) {
+	g := NewWithT(t)
+	db, err := bolt.Open(path.Join(t.TempDir(), "./test.bolt"), 0666, &bolt.Options{Timeout: 1 * time.Second})
+	g.Expect(err).ToNot(HaveOccurred())
+	defer db.Close()
+	g.Expect(initializeBuckets(db)).ToNot(HaveOccurred())
+	s := storage.AsStore(&Store{boltDb: db, leaseInterval: 10})
+
+	next := time.Now().Add(-time.Second)
+	global := &storage.TransGlobalStore{Gid: "gid1", Status: "submitted", NextCronTime: &next}
+	g.Expect(s.MaySaveNewTrans(global, []storage.TransBranchStore{{Gid: "gid1", BranchID: "01"}})).ToNot(HaveOccurred())
+
+	locked := s.LockOneGlobalTrans(0)
+	g.Expect(locked).ToNot(BeNil())
+	g.Expect(locked.Owner).ToNot(BeEmpty())
+	g.Expect(s.LockOneGlobalTrans(0)).To(BeNil())
+	g.Expect(s.RenewLease("gid1", locked.Owner)).ToNot(HaveOccurred())
+	g.Expect(s.RenewLease("gid1", "other")).To(Equal(storage.ErrNotFound))
+
+	owner := locked.Owner
+	locked.NextCronTime = &next
+	s.ReleaseLease(locked)
+	g.Expect(s.RenewLease("gid1", owner)).To(Equal(storage.ErrNotFound))
+	relocked := s.LockOneGlobalTrans(0)
+	g.Expect(relocked).ToNot(BeNil())
+	g.Expect(relocked.Owner).ToNot(Equal(owner))
+
+	s.ChangeGlobalStatus(relocked, "succeed", []string{}, true)
+	s.ReleaseLease(relocked)
+	g.Expect(s.LockOneGlobalTrans(time.Hour)).To(BeNil())
+}
+
+func TestLockGlobalTransBatch(t *testing.T) {
+	g := NewWithT(t)
+	db, err := bolt.Open(path.Join(t.TempDir(), "./test.bolt"), 0666, &bolt.Options{Timeout: 1 * time.Second})
+	g.Expect(err).ToNot(HaveOccurred())
+	defer db.Close()
+	g.Expect(initializeBuckets(db)).ToNot(HaveOccurred())
+	s := storage.AsStore(&Store{boltDb: db, leaseInterval: 10})
+
+	next := time.Now().Add(-time.Second)
+	for _, gid := range []string{"gid1", "gid2", "gid3"} {
+		global := &storage.TransGlobalStore{Gid: gid, Status: "submitted", NextCronTime: &next}
+		g.Expect(s.MaySaveNewTrans(global, []storage.TransBranchStore{{Gid: gid, BranchID: "01"}})).ToNot(HaveOccurred())
+	}
+	s.ChangeGlobalStatus(&storage.TransGlobalStore{Gid: "gid1", Status: "submitted"}, "succeed", []string{}, true)
+
+	globals := s.LockGlobalTransBatch(0, 1, storage.ShardFilter{})
+	g.Expect(globals).To(HaveLen(1))
+	g.Expect(globals[0].Gid).To(Equal("gid2"))
+	globals = s.LockGlobalTransBatch(0, 10, storage.ShardFilter{})
+	g.Expect(globals).To(HaveLen(1))
+	g.Expect(globals[0].Gid).To(Equal("gid3"))
+	g.Expect(s.LockGlobalTransBatch(0, 10, storage.ShardFilter{})).To(BeEmpty())
+}
+
+func TestLockGlobalTransBatchShards(t *testing.T) {
+	g := NewWithT(t)
+	db, err := bolt.Open(path.Join(t.TempDir(), "./test.bolt"), 0666, &bolt.Options{Timeout: 1 * time.Second})
+	g.Expect(err).ToNot(HaveOccurred())
+	defer db.Close()
+	g.Expect(initializeBuckets(db)).ToNot(HaveOccurred())
+	s := storage.AsStore(&Store{boltDb: db, leaseInterval: 10})
+
+	next := time.Now().Add(-time.Second)
+	gids := []string{"gid1", "gid2", "gid3", "gid4"}
+	for _, gid := range gids {
+		global := &storage.TransGlobalStore{Gid: gid, Status: "submitted", NextCronTime: &next}
+		g.Expect(s.MaySaveNewTrans(global, []storage.TransBranchStore{{Gid: gid, BranchID: "01"}})).ToNot(HaveOccurred())
+	}
+
+	locked := map[string]bool{}
+	for _, shard := range []int64{0, 1} {
+		for _, global := range s.LockGlobalTransBatch(0, 10, storage.ShardFilter{Total: 2, Owned: []int64{shard}}) {
+			g.Expect(inShards(global.Gid, storage.ShardFilter{Total: 2, Owned: []int64{shard}})).To(BeTrue())
+			locked[global.Gid] = true
+		}
+	}
+	g.Expect(locked).To(HaveLen(len(gids)))
+}
+
+func TestInstances(t *testing.T) {
+	g := NewWithT(t)
+	s := storage.AsStore(&Store{})
+	s.KeepAliveInstance("b", time.Second)
+	s.KeepAliveInstance("a", time.Second)
+	s.KeepAliveInstance("c", -time.Second)
+	g.Expect(s.ListInstances()).To(Equal([]string{"a", "b"}))
+}
+
+func TestScanTransGlobalStores(t *testing.T) {
+	g := NewWithT(t)
+	db, err := bolt.Open(path.Join(t.TempDir(), "./test.bolt"), 0666, &bolt.Options{Timeout: 1 * time.Second})
+	g.Expect(err).ToNot(HaveOccurred())
+	defer db.Close()
+	g.Expect(initializeBuckets(db)).ToNot(HaveOccurred())
+	s := storage.AsStore(&Store{boltDb: db, leaseInterval: 10})
+
+	next := time.Now()
+	for _, gid := range []string{"gid1", "gid2", "gid3"} {
+		global := &storage.TransGlobalStore{Gid: gid, Status: "submitted", NextCronTime: &next}
+		g.Expect(s.MaySaveNewTrans(global, nil)).ToNot(HaveOccurred())
+	}
+
+	position := ""
+	scanned := []string{}
+	for {
+		for _, global := range s.ScanTransGlobalStores(&position, 2) {
+			scanned = append(scanned, global.Gid)
+		}
+		if position == "" {
+			break
+		}
+	}
+	g.Expect(scanned).To(Equal([]string{"gid1", "gid2", "gid3"}))
+}
+
+func TestConformance(t *testing.T) {
+	config.MustLoadConfig("")
+	db, err := bolt.Open(path.Join(t.TempDir(), "./test.bolt"), 0666, &bolt.Options{Timeout: 1 * time.Second})
+	NewWithT(t).Expect(err).ToNot(HaveOccurred())
+	defer db.Close()
+	NewWithT(t).Expect(initializeBuckets(db)).ToNot(HaveOccurred())
+
+	storetest.Run(t, storage.AsStore(&Store{boltDb: db, leaseInterval: config.Config.CronLeaseInterval}))
+}
+
+func TestContext(t *testing.T) {
+	g := NewWithT(t)
+	db, err := bolt.Open(path.Join(t.TempDir(), "./test.bolt"), 0666, &bolt.Options{Timeout: 1 * time.Second})
+	g.Expect(err).ToNot(HaveOccurred())
+	defer db.Close()
+	g.Expect(initializeBuckets(db)).ToNot(HaveOccurred())
+	s := &Store{boltDb: db, leaseInterval: 10}
+	g.Expect(storage.AsStoreV2(storage.AsStore(s))).To(BeIdenticalTo(s))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	next := time.Now().Add(time.Second)
+	global := &storage.TransGlobalStore{Gid: "gid1", Status: "submitted", NextCronTime: &next}
+	g.Expect(s.MaySaveNewTransContext(ctx, global, nil)).ToNot(HaveOccurred())
+	_, err = s.FindTransGlobalStoreContext(ctx, "gid2")
+	g.Expect(err).To(Equal(storage.ErrNotFound))
+
+	cancel()
+	_, err = s.FindTransGlobalStoreContext(ctx, "gid1")
+	g.Expect(err).To(Equal(context.Canceled))
+	err = s.ChangeGlobalStatusContext(ctx, global, "succeed", []string{}, true)
+	g.Expect(err).To(Equal(context.Canceled))
+	g.Expect(storage.AsStore(s).FindTransGlobalStore("gid1").Status).To(Equal("submitted"))
+}
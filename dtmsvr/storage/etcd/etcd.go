@@ -0,0 +1,497 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package etcd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lithammer/shortuuid/v3"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/dtm-labs/dtm/dtmcli"
+	"github.com/dtm-labs/dtm/dtmcli/dtmimp"
+	"github.com/dtm-labs/dtm/dtmcli/logger"
+	"github.com/dtm-labs/dtm/dtmsvr/config"
+	"github.com/dtm-labs/dtm/dtmsvr/storage"
+	"github.com/dtm-labs/dtm/dtmutil"
+)
+
+var conf = &config.Config
+
+var ctx = context.Background()
+
+// Store is the storage with etcd. etcd replicates the data with raft, so a dtm cluster with a etcd cluster of 3 nodes
+// is highly available without mysql or redis.
+// a trans is saved in the keys {prefix}/g/{gid} for the global trans, {prefix}/b/{gid} for the branches,
+// and {prefix}/n/{next cron time}/{gid} as the index of the unfinished trans, which is ordered by next cron time.
+// every change reads the global trans and the branches, and commits in a txn if both are not modified meanwhile
+type Store struct {
+}
+
+func globalKey(gid string) string {
+	return conf.Store.EtcdPrefix + "/g/" + gid
+}
+
+func branchesKey(gid string) string {
+	return conf.Store.EtcdPrefix + "/b/" + gid
+}
+
+func cronPrefix() string {
+	return conf.Store.EtcdPrefix + "/n/"
+}
+
+// cronKey is the index key of the next cron time. the time is padded, so that the keys are ordered by time.
+// finished trans have no index
+func cronKey(global *storage.TransGlobalStore) string {
+	if !isUnfinished(global.Status) || global.NextCronTime == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s%011d/%s", cronPrefix(), global.NextCronTime.Unix(), global.Gid)
+}
+
+func gidOfCronKey(key string) string {
+	suffix := strings.TrimPrefix(key, cronPrefix())
+	return suffix[strings.Index(suffix, "/")+1:]
+}
+
+func instanceKey(instance string) string {
+	return conf.Store.EtcdPrefix + "/i/" + instance
+}
+
+func isUnfinished(status string) bool {
+	return status == dtmcli.StatusPrepared || status == dtmcli.StatusAborting || status == dtmcli.StatusSubmitted
+}
+
+// Ping gets a key from etcd
+func (s *Store) Ping() error {
+	c, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	_, err := etcdGet().Get(c, conf.Store.EtcdPrefix+"/ping")
+	return err
+}
+
+// PopulateData deletes all the keys with prefix if skipDrop is false
+func (s *Store) PopulateData(skipDrop bool) {
+	if !skipDrop {
+		_, err := etcdGet().Delete(ctx, conf.Store.EtcdPrefix+"/", clientv3.WithPrefix())
+		logger.Infof("delete etcd keys with prefix %s. result: %v", conf.Store.EtcdPrefix, err)
+		dtmimp.E2P(err)
+	}
+}
+
+// FindTransGlobalStore finds GlobalTrans data by gid
+func (s *Store) FindTransGlobalStore(gid string) *storage.TransGlobalStore {
+	r, err := etcdGet().Get(ctx, globalKey(gid))
+	dtmimp.E2P(err)
+	if len(r.Kvs) == 0 {
+		return nil
+	}
+	global := &storage.TransGlobalStore{}
+	dtmimp.MustUnmarshal(r.Kvs[0].Value, global)
+	return global
+}
+
+// ScanTransGlobalStores lists GlobalTrans data in the order of gid
+func (s *Store) ScanTransGlobalStores(position *string, limit int64) []storage.TransGlobalStore {
+	prefix := globalKey("")
+	from := prefix
+	if *position != "" {
+		from = globalKey(*position) + "\x00"
+	}
+	r, err := etcdGet().Get(ctx, from, clientv3.WithRange(clientv3.GetPrefixRangeEnd(prefix)), clientv3.WithLimit(limit))
+	dtmimp.E2P(err)
+	globals := []storage.TransGlobalStore{}
+	for _, kv := range r.Kvs {
+		global := storage.TransGlobalStore{}
+		dtmimp.MustUnmarshal(kv.Value, &global)
+		globals = append(globals, global)
+	}
+	if r.More {
+		*position = globals[len(globals)-1].Gid
+	} else {
+		*position = ""
+	}
+	return globals
+}
+
+// FindBranches finds Branch data by gid
+func (s *Store) FindBranches(gid string) []storage.TransBranchStore {
+	r, err := etcdGet().Get(ctx, branchesKey(gid))
+	dtmimp.E2P(err)
+	branches := []storage.TransBranchStore{}
+	if len(r.Kvs) > 0 {
+		dtmimp.MustUnmarshal(r.Kvs[0].Value, &branches)
+	}
+	return branches
+}
+
+// errNoChange gives up the change of updateTrans if nothing changed
+var errNoChange = errors.New("no change")
+
+// UpdateBranches updates branches info
+func (s *Store) UpdateBranches(branches []storage.TransBranchStore, updates []string) (int, error) {
+	updated := 0
+	for _, gid := range storage.BranchGids(branches) {
+		changed := []int{}
+		err := updateTrans(gid, func(global *storage.TransGlobalStore, saved *[]storage.TransBranchStore) (err error) {
+			changed, err = storage.MergeBranchUpdates(*saved, branches, updates)
+			if err == nil && len(changed) == 0 {
+				err = errNoChange
+			}
+			return
+		})
+		if errors.Is(err, storage.ErrNotFound) || errors.Is(err, errNoChange) {
+			continue
+		} else if err != nil {
+			return updated, err
+		}
+		updated += len(changed)
+	}
+	return updated, nil
+}
+
+// transOps builds the ops to save global and branches with the lease of the expire.
+// the cron index is saved only for the unfinished trans
+func transOps(global *storage.TransGlobalStore, branches []storage.TransBranchStore) []clientv3.Op {
+	expire := conf.Store.DataExpire
+	if !isUnfinished(global.Status) {
+		expire = conf.Store.FinishedDataExpire
+	}
+	lease := clientv3.WithLease(leaseGet(expire))
+	ops := []clientv3.Op{
+		clientv3.OpPut(globalKey(global.Gid), dtmimp.MustMarshalString(global), lease),
+		clientv3.OpPut(branchesKey(global.Gid), dtmimp.MustMarshalString(branches), lease),
+	}
+	if key := cronKey(global); key != "" {
+		ops = append(ops, clientv3.OpPut(key, "", lease))
+	}
+	return ops
+}
+
+// MaySaveNewTrans creates a new trans
+func (s *Store) MaySaveNewTrans(global *storage.TransGlobalStore, branches []storage.TransBranchStore) error {
+	if branches == nil {
+		branches = []storage.TransBranchStore{}
+	}
+	r, err := etcdGet().Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(globalKey(global.Gid)), "=", 0)).
+		Then(transOps(global, branches)...).
+		Commit()
+	global.Steps = nil
+	global.Payloads = nil
+	if err == nil && !r.Succeeded {
+		err = storage.ErrUniqueConflict
+	}
+	return err
+}
+
+// MaySaveNewTransBatch creates many trans, one txn for each trans
+func (s *Store) MaySaveNewTransBatch(globals []*storage.TransGlobalStore, branches [][]storage.TransBranchStore) []error {
+	errs := make([]error, len(globals))
+	for i, g := range globals {
+		errs[i] = s.MaySaveNewTrans(g, branches[i])
+	}
+	return errs
+}
+
+// updateTrans reads the trans of gid, and saves the changes made by update.
+// if the trans is modified by others before saving, it is read and updated again.
+// update returns an error to give up the change
+func updateTrans(gid string, update func(global *storage.TransGlobalStore, branches *[]storage.TransBranchStore) error) error {
+	gkey, bkey := globalKey(gid), branchesKey(gid)
+	for {
+		r, err := etcdGet().Txn(ctx).Then(clientv3.OpGet(gkey), clientv3.OpGet(bkey)).Commit()
+		if err != nil {
+			return err
+		}
+		gkvs, bkvs := r.Responses[0].GetResponseRange().Kvs, r.Responses[1].GetResponseRange().Kvs
+		if len(gkvs) == 0 {
+			return storage.ErrNotFound
+		}
+		global := &storage.TransGlobalStore{}
+		dtmimp.MustUnmarshal(gkvs[0].Value, global)
+		branches := []storage.TransBranchStore{}
+		bRevision := int64(0)
+		if len(bkvs) > 0 {
+			dtmimp.MustUnmarshal(bkvs[0].Value, &branches)
+			bRevision = bkvs[0].ModRevision
+		}
+		oldCronKey := cronKey(global)
+		err = update(global, &branches)
+		if err != nil {
+			return err
+		}
+		ops := transOps(global, branches)
+		if oldCronKey != "" && oldCronKey != cronKey(global) {
+			ops = append(ops, clientv3.OpDelete(oldCronKey))
+		}
+		tr, err := etcdGet().Txn(ctx).
+			If(clientv3.Compare(clientv3.ModRevision(gkey), "=", gkvs[0].ModRevision),
+				clientv3.Compare(clientv3.ModRevision(bkey), "=", bRevision)).
+			Then(ops...).
+			Commit()
+		if err != nil || tr.Succeeded {
+			return err
+		}
+	}
+}
+
+// LockGlobalSaveBranches saves branches if the status of the global trans is status
+func (s *Store) LockGlobalSaveBranches(gid string, status string, branches []storage.TransBranchStore, branchStart int) {
+	err := updateTrans(gid, func(global *storage.TransGlobalStore, saved *[]storage.TransBranchStore) error {
+		if global.Status != status {
+			return storage.ErrNotFound
+		}
+		if branchStart == -1 {
+			*saved = append(*saved, branches...)
+		} else {
+			copy((*saved)[branchStart:], branches)
+		}
+		return nil
+	})
+	dtmimp.E2P(err)
+}
+
+// ChangeGlobalStatus changes global trans status. finished trans will expire in FinishedDataExpire
+func (s *Store) ChangeGlobalStatus(global *storage.TransGlobalStore, newStatus string, updates []string, finished bool) {
+	old := global.Status
+	global.Status = newStatus
+	err := updateTrans(global.Gid, func(saved *storage.TransGlobalStore, branches *[]storage.TransBranchStore) error {
+		if saved.Status != old {
+			return storage.ErrNotFound
+		}
+		*saved = *global
+		return nil
+	})
+	dtmimp.E2P(err)
+}
+
+// TouchCronTime updates cronTime
+func (s *Store) TouchCronTime(global *storage.TransGlobalStore, nextCronInterval int64, nextCronTime *time.Time) {
+	global.UpdateTime = dtmutil.GetNextTime(0)
+	global.NextCronTime = nextCronTime
+	global.NextCronInterval = nextCronInterval
+	err := updateTrans(global.Gid, func(saved *storage.TransGlobalStore, branches *[]storage.TransBranchStore) error {
+		if saved.Status != global.Status {
+			return storage.ErrNotFound
+		}
+		*saved = *global
+		return nil
+	})
+	dtmimp.E2P(err)
+}
+
+// LockOneGlobalTrans finds GlobalTrans, and leases it to a new owner for CronLeaseInterval
+func (s *Store) LockOneGlobalTrans(expireIn time.Duration) *storage.TransGlobalStore {
+	globals := s.LockGlobalTransBatch(expireIn, 1, storage.ShardFilter{})
+	if len(globals) == 0 {
+		return nil
+	}
+	return globals[0]
+}
+
+// LockGlobalTransBatch finds at most limit GlobalTrans in shards, and leases them to a new owner for CronLeaseInterval.
+// the cron index is scanned in the order of next cron time, and every trans is locked by a txn
+func (s *Store) LockGlobalTransBatch(expireIn time.Duration, limit int64, shards storage.ShardFilter) []*storage.TransGlobalStore {
+	expired := time.Now().Add(expireIn).Unix()
+	end := fmt.Sprintf("%s%011d", cronPrefix(), expired+1)
+	owned := map[int64]bool{}
+	for _, shard := range shards.Owned {
+		owned[shard] = true
+	}
+	owner := shortuuid.New()
+	globals := []*storage.TransGlobalStore{}
+	locked := map[string]bool{} // the locked trans may be moved to the scanned range again
+	page := limit * dtmimp.If(shards.Total > 0, shards.Total, int64(1)).(int64)
+	from := cronPrefix()
+	for int64(len(globals)) < limit {
+		r, err := etcdGet().Get(ctx, from, clientv3.WithRange(end), clientv3.WithLimit(page), clientv3.WithKeysOnly())
+		dtmimp.E2P(err)
+		for _, kv := range r.Kvs {
+			key := string(kv.Key)
+			gid := gidOfCronKey(key)
+			if int64(len(globals)) >= limit || locked[gid] ||
+				shards.Total > 0 && !owned[int64(crc32.ChecksumIEEE([]byte(gid)))%shards.Total] {
+				continue
+			}
+			if global := s.lockTrans(gid, key, owner); global != nil {
+				globals = append(globals, global)
+				locked[gid] = true
+			}
+		}
+		if !r.More {
+			break
+		}
+		from = string(r.Kvs[len(r.Kvs)-1].Key) + "\x00"
+	}
+	return globals
+}
+
+// lockTrans leases the trans to owner if the cron index of it is not changed
+func (s *Store) lockTrans(gid string, key string, owner string) *storage.TransGlobalStore {
+	var locked *storage.TransGlobalStore
+	err := updateTrans(gid, func(global *storage.TransGlobalStore, branches *[]storage.TransBranchStore) error {
+		if cronKey(global) != key {
+			return storage.ErrNotFound
+		}
+		next := time.Now().Add(time.Duration(conf.CronLeaseInterval) * time.Second)
+		global.NextCronTime = &next
+		global.Owner = owner
+		locked = global
+		return nil
+	})
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil
+	}
+	dtmimp.E2P(err)
+	return locked
+}
+
+// RenewLease extends the lease of owner for another CronLeaseInterval
+func (s *Store) RenewLease(gid string, owner string) error {
+	return updateTrans(gid, func(global *storage.TransGlobalStore, branches *[]storage.TransBranchStore) error {
+		if global.Owner != owner || !isUnfinished(global.Status) {
+			return storage.ErrNotFound
+		}
+		next := time.Now().Add(time.Duration(conf.CronLeaseInterval) * time.Second)
+		global.NextCronTime = &next
+		return nil
+	})
+}
+
+// ReleaseLease releases the lease of global.Owner, and saves the next cron time of global
+func (s *Store) ReleaseLease(global *storage.TransGlobalStore) {
+	owner := global.Owner
+	global.UpdateTime = dtmutil.GetNextTime(0)
+	global.Owner = ""
+	err := updateTrans(global.Gid, func(saved *storage.TransGlobalStore, branches *[]storage.TransBranchStore) error {
+		if saved.Owner != owner || saved.Status != global.Status || !isUnfinished(saved.Status) {
+			return storage.ErrNotFound
+		}
+		*saved = *global
+		return nil
+	})
+	if !errors.Is(err, storage.ErrNotFound) {
+		dtmimp.E2P(err)
+	}
+}
+
+// ResetCronTime rest nextCronTime
+// Prevent multiple backoff from causing NextCronTime to be too long
+func (s *Store) ResetCronTime(timeout time.Duration, limit int64) (succeedCount int64, hasRemaining bool, err error) {
+	from := fmt.Sprintf("%s%011d", cronPrefix(), time.Now().Add(timeout).Unix()+1)
+	r, err := etcdGet().Get(ctx, from, clientv3.WithRange(clientv3.GetPrefixRangeEnd(cronPrefix())),
+		clientv3.WithLimit(limit+1), clientv3.WithKeysOnly())
+	if err != nil {
+		return 0, false, err
+	}
+	hasRemaining = int64(len(r.Kvs)) > limit
+	for i := 0; i < len(r.Kvs) && int64(i) < limit; i++ {
+		key := string(r.Kvs[i].Key)
+		err = updateTrans(gidOfCronKey(key), func(global *storage.TransGlobalStore, branches *[]storage.TransBranchStore) error {
+			if cronKey(global) != key {
+				return storage.ErrNotFound
+			}
+			now := time.Now()
+			global.NextCronTime = &now
+			return nil
+		})
+		if err == nil {
+			succeedCount++
+		} else if !errors.Is(err, storage.ErrNotFound) {
+			return succeedCount, false, err
+		}
+	}
+	return succeedCount, hasRemaining, nil
+}
+
+// KeepAliveInstance registers instance with a lease of expireIn
+func (s *Store) KeepAliveInstance(instance string, expireIn time.Duration) {
+	if expireIn <= 0 { // etcd grants a lease of min ttl for a non-positive ttl
+		_, err := etcdGet().Delete(ctx, instanceKey(instance))
+		dtmimp.E2P(err)
+		return
+	}
+	lease, err := etcdGet().Grant(ctx, int64((expireIn+time.Second-1)/time.Second))
+	dtmimp.E2P(err)
+	_, err = etcdGet().Put(ctx, instanceKey(instance), "", clientv3.WithLease(lease.ID))
+	dtmimp.E2P(err)
+}
+
+// ListInstances lists alive instances
+func (s *Store) ListInstances() []string {
+	prefix := instanceKey("")
+	r, err := etcdGet().Get(ctx, prefix, clientv3.WithPrefix(), clientv3.WithKeysOnly())
+	dtmimp.E2P(err)
+	instances := []string{}
+	for _, kv := range r.Kvs {
+		instances = append(instances, strings.TrimPrefix(string(kv.Key), prefix))
+	}
+	sort.Strings(instances)
+	return instances
+}
+
+// leaseReuse is the duration in seconds that a lease is shared by the keys with the same expire.
+// a lease is granted with ttl of expire + leaseReuse, so the keys expire in [expire, expire + leaseReuse]
+const leaseReuse = 60
+
+type sharedLease struct {
+	id        clientv3.LeaseID
+	grantedAt time.Time
+}
+
+var (
+	leases   = map[int64]sharedLease{}
+	leasesMu sync.Mutex
+)
+
+// leaseGet returns a shared lease for the keys expiring in expire seconds, so that not every key needs a lease
+func leaseGet(expire int64) clientv3.LeaseID {
+	leasesMu.Lock()
+	defer leasesMu.Unlock()
+	if l, ok := leases[expire]; ok && time.Since(l.grantedAt) < leaseReuse*time.Second {
+		return l.id
+	}
+	r, err := etcdGet().Grant(ctx, expire+leaseReuse)
+	dtmimp.E2P(err)
+	leases[expire] = sharedLease{id: r.ID, grantedAt: time.Now()}
+	return r.ID
+}
+
+var (
+	client     *clientv3.Client
+	clientOnce sync.Once
+)
+
+// endpoints returns Endpoints of the config, or Host:Port if Endpoints is empty
+func endpoints() []string {
+	if conf.Store.Endpoints != "" {
+		return strings.Split(conf.Store.Endpoints, ",")
+	}
+	return []string{fmt.Sprintf("%s:%d", conf.Store.Host, conf.Store.Port)}
+}
+
+func etcdGet() *clientv3.Client {
+	clientOnce.Do(func() {
+		logger.Infof("connecting to etcd: %v", endpoints())
+		c, err := clientv3.New(clientv3.Config{
+			Endpoints: endpoints(),
+			Username:  conf.Store.User,
+			Password:  conf.Store.Password,
+		})
+		dtmimp.E2P(err)
+		client = c
+	})
+	return client
+}
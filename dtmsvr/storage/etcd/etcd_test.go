@@ -0,0 +1,112 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package etcd
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.etcd.io/etcd/server/v3/embed"
+
+	"github.com/dtm-labs/dtm/dtmsvr/config"
+	"github.com/dtm-labs/dtm/dtmsvr/storage/storetest"
+)
+
+func freeURL(t *testing.T) url.URL {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer l.Close()
+	return url.URL{Scheme: "http", Host: l.Addr().String()}
+}
+
+// startCluster starts an etcd cluster of n nodes in process
+func startCluster(t *testing.T, n int) []*embed.Etcd {
+	cfgs := []*embed.Config{}
+	initial := []string{}
+	for i := 0; i < n; i++ {
+		cfg := embed.NewConfig()
+		cfg.Name = fmt.Sprintf("node%d", i)
+		cfg.Dir = t.TempDir()
+		cfg.LogLevel = "error"
+		cfg.InitialClusterToken = "dtm-test"
+		peer, client := freeURL(t), freeURL(t)
+		cfg.LPUrls, cfg.APUrls = []url.URL{peer}, []url.URL{peer}
+		cfg.LCUrls, cfg.ACUrls = []url.URL{client}, []url.URL{client}
+		initial = append(initial, fmt.Sprintf("%s=%s", cfg.Name, peer.String()))
+		cfgs = append(cfgs, cfg)
+	}
+	nodes := make([]*embed.Etcd, n)
+	errs := make(chan error, n)
+	for i, cfg := range cfgs {
+		cfg.InitialCluster = strings.Join(initial, ",")
+		go func(i int, cfg *embed.Config) { // the nodes wait for each other to start
+			e, err := embed.StartEtcd(cfg)
+			nodes[i] = e
+			errs <- err
+		}(i, cfg)
+	}
+	for range cfgs {
+		assert.Nil(t, <-errs)
+	}
+	t.Cleanup(func() {
+		for _, e := range nodes {
+			if e != nil {
+				e.Close()
+			}
+		}
+	})
+	for _, e := range nodes {
+		select {
+		case <-e.Server.ReadyNotify():
+		case <-time.After(30 * time.Second):
+			t.Fatal("etcd cluster not ready in 30s")
+		}
+	}
+	return nodes
+}
+
+// waitLeader waits for a leader elected by the nodes, and returns its index
+func waitLeader(t *testing.T, nodes []*embed.Etcd) int {
+	for start := time.Now(); time.Since(start) < 30*time.Second; time.Sleep(100 * time.Millisecond) {
+		for i, e := range nodes {
+			if e != nil && e.Server.Leader() == e.Server.ID() {
+				return i
+			}
+		}
+	}
+	t.Fatal("no leader elected in 30s")
+	return -1
+}
+
+func TestConformance(t *testing.T) {
+	config.MustLoadConfig("")
+	nodes := startCluster(t, 3)
+	endpoints := []string{}
+	for _, e := range nodes {
+		endpoints = append(endpoints, e.Config().ACUrls[0].Host)
+	}
+	conf.Store.Driver = config.Etcd
+	conf.Store.Endpoints = strings.Join(endpoints, ",")
+	conf.Store.User = ""
+	conf.Store.Password = ""
+
+	s := &Store{}
+	s.PopulateData(false)
+	storetest.Run(t, s)
+
+	// the store keeps working with the other nodes after the leader stops
+	leader := waitLeader(t, nodes)
+	nodes[leader].Close()
+	nodes[leader] = nil
+	assert.NotEqual(t, leader, waitLeader(t, nodes))
+	t.Run("LeaderStopped", func(t *testing.T) { storetest.Run(t, s) })
+}
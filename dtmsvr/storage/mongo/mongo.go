@@ -0,0 +1,440 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lithammer/shortuuid/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/dtm-labs/dtm/dtmcli"
+	"github.com/dtm-labs/dtm/dtmcli/dtmimp"
+	"github.com/dtm-labs/dtm/dtmcli/logger"
+	"github.com/dtm-labs/dtm/dtmsvr/config"
+	"github.com/dtm-labs/dtm/dtmsvr/storage"
+	"github.com/dtm-labs/dtm/dtmutil"
+)
+
+var conf = &config.Config
+
+var ctx = context.Background()
+
+var unfinished = bson.M{"$in": []string{dtmcli.StatusPrepared, dtmcli.StatusAborting, dtmcli.StatusSubmitted}}
+
+// Store is the storage with mongo. a global trans and its branches are saved in one document,
+// so that every change is atomic without mongo transactions.
+// the fields used by queries are saved in the document, and the trans is saved as json like redis
+type Store struct {
+}
+
+// transDoc is the document of a global trans
+type transDoc struct {
+	Gid              string    `bson:"_id"`
+	Status           string    `bson:"status"`
+	NextCronTime     time.Time `bson:"next_cron_time"`
+	NextCronInterval int64     `bson:"next_cron_interval"`
+	Owner            string    `bson:"owner"`
+	Shard            int64     `bson:"shard"`     // crc32 of gid, used by sharded cron
+	ExpireAt         time.Time `bson:"expire_at"` // ttl index
+	Data             string    `bson:"data"`      // json of TransGlobalStore
+	Branches         []string  `bson:"branches"`  // json of TransBranchStore
+}
+
+func (d *transDoc) toGlobal() *storage.TransGlobalStore {
+	global := &storage.TransGlobalStore{}
+	dtmimp.MustUnmarshalString(d.Data, global)
+	global.Status = d.Status
+	global.Owner = d.Owner
+	next := d.NextCronTime
+	global.NextCronTime = &next
+	global.NextCronInterval = d.NextCronInterval
+	return global
+}
+
+type instanceDoc struct {
+	Instance string    `bson:"_id"`
+	ExpireAt time.Time `bson:"expire_at"` // ttl index
+}
+
+// Ping execs ping cmd to mongo
+func (s *Store) Ping() error {
+	return mongoGet().Ping(ctx, nil)
+}
+
+// PopulateData drops the collections if skipDrop is false, and creates the indexes
+func (s *Store) PopulateData(skipDrop bool) {
+	if !skipDrop {
+		err := transColl().Drop(ctx)
+		if err == nil {
+			err = instanceColl().Drop(ctx)
+		}
+		logger.Infof("drop mongo collections. result: %v", err)
+		dtmimp.E2P(err)
+	}
+	ttl := options.Index().SetExpireAfterSeconds(0)
+	_, err := transColl().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_cron_time", Value: 1}}},
+		{Keys: bson.D{{Key: "expire_at", Value: 1}}, Options: ttl},
+	})
+	if err == nil {
+		_, err = instanceColl().Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "expire_at", Value: 1}}, Options: ttl})
+	}
+	dtmimp.E2P(err)
+}
+
+// FindTransGlobalStore finds GlobalTrans data by gid
+func (s *Store) FindTransGlobalStore(gid string) *storage.TransGlobalStore {
+	doc := transDoc{}
+	err := transColl().FindOne(ctx, bson.M{"_id": gid}, options.FindOne().SetProjection(bson.M{"branches": 0})).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return nil
+	}
+	dtmimp.E2P(err)
+	return doc.toGlobal()
+}
+
+// ScanTransGlobalStores lists GlobalTrans data in the order of gid
+func (s *Store) ScanTransGlobalStores(position *string, limit int64) []storage.TransGlobalStore {
+	cursor, err := transColl().Find(ctx, bson.M{"_id": bson.M{"$gt": *position}},
+		options.Find().SetProjection(bson.M{"branches": 0}).SetSort(bson.M{"_id": 1}).SetLimit(limit))
+	dtmimp.E2P(err)
+	docs := []transDoc{}
+	dtmimp.E2P(cursor.All(ctx, &docs))
+	globals := []storage.TransGlobalStore{}
+	for i := range docs {
+		globals = append(globals, *docs[i].toGlobal())
+	}
+	if int64(len(docs)) < limit {
+		*position = ""
+	} else {
+		*position = docs[len(docs)-1].Gid
+	}
+	return globals
+}
+
+// FindBranches finds Branch data by gid
+func (s *Store) FindBranches(gid string) []storage.TransBranchStore {
+	doc := transDoc{}
+	err := transColl().FindOne(ctx, bson.M{"_id": gid}, options.FindOne().SetProjection(bson.M{"branches": 1})).Decode(&doc)
+	if err != mongo.ErrNoDocuments {
+		dtmimp.E2P(err)
+	}
+	branches := make([]storage.TransBranchStore, len(doc.Branches))
+	for i, b := range doc.Branches {
+		dtmimp.MustUnmarshalString(b, &branches[i])
+	}
+	return branches
+}
+
+// UpdateBranches updates branches info.
+// the branches of a gid are merged in memory, and saved if they are not changed by others, or merged again
+func (s *Store) UpdateBranches(branches []storage.TransBranchStore, updates []string) (int, error) {
+	updated := 0
+	for _, gid := range storage.BranchGids(branches) {
+		for {
+			doc := transDoc{}
+			err := transColl().FindOne(ctx, bson.M{"_id": gid}, options.FindOne().SetProjection(bson.M{"branches": 1})).Decode(&doc)
+			if err == mongo.ErrNoDocuments {
+				break
+			} else if err != nil {
+				return updated, err
+			}
+			saved := make([]storage.TransBranchStore, len(doc.Branches))
+			for i, b := range doc.Branches {
+				dtmimp.MustUnmarshalString(b, &saved[i])
+			}
+			changed, err := storage.MergeBranchUpdates(saved, branches, updates)
+			if err != nil {
+				return updated, err
+			} else if len(changed) == 0 {
+				break
+			}
+			filter, set := bson.M{"_id": gid}, bson.M{}
+			for _, i := range changed {
+				filter[fmt.Sprintf("branches.%d", i)] = doc.Branches[i]
+				set[fmt.Sprintf("branches.%d", i)] = dtmimp.MustMarshalString(saved[i])
+			}
+			r, err := transColl().UpdateOne(ctx, filter, bson.M{"$set": set})
+			if err != nil {
+				return updated, err
+			}
+			if r.MatchedCount > 0 {
+				updated += len(changed)
+				break
+			}
+		}
+	}
+	return updated, nil
+}
+
+// LockGlobalSaveBranches saves branches if the status of the global trans is status
+func (s *Store) LockGlobalSaveBranches(gid string, status string, branches []storage.TransBranchStore, branchStart int) {
+	values := marshalBranches(branches)
+	update := bson.M{}
+	if branchStart == -1 {
+		update["$push"] = bson.M{"branches": bson.M{"$each": values}}
+	} else {
+		set := bson.M{}
+		for i, v := range values {
+			set[fmt.Sprintf("branches.%d", branchStart+i)] = v
+		}
+		update["$set"] = set
+	}
+	r, err := transColl().UpdateOne(ctx, bson.M{"_id": gid, "status": status}, update)
+	dtmimp.E2P(err)
+	if r.MatchedCount == 0 {
+		dtmimp.E2P(storage.ErrNotFound)
+	}
+}
+
+func newTransDoc(global *storage.TransGlobalStore, branches []storage.TransBranchStore) *transDoc {
+	doc := &transDoc{
+		Gid:              global.Gid,
+		Status:           global.Status,
+		NextCronTime:     *global.NextCronTime,
+		NextCronInterval: global.NextCronInterval,
+		Owner:            global.Owner,
+		Shard:            int64(crc32.ChecksumIEEE([]byte(global.Gid))),
+		ExpireAt:         time.Now().Add(time.Duration(conf.Store.DataExpire) * time.Second),
+		Data:             dtmimp.MustMarshalString(global),
+		Branches:         marshalBranches(branches),
+	}
+	global.Steps = nil
+	global.Payloads = nil
+	return doc
+}
+
+func marshalBranches(branches []storage.TransBranchStore) []string {
+	values := []string{}
+	for _, b := range branches {
+		values = append(values, dtmimp.MustMarshalString(b))
+	}
+	return values
+}
+
+// MaySaveNewTrans creates a new trans
+func (s *Store) MaySaveNewTrans(global *storage.TransGlobalStore, branches []storage.TransBranchStore) error {
+	_, err := transColl().InsertOne(ctx, newTransDoc(global, branches))
+	if mongo.IsDuplicateKeyError(err) {
+		return storage.ErrUniqueConflict
+	}
+	return err
+}
+
+// MaySaveNewTransBatch creates many trans with one unordered insert
+func (s *Store) MaySaveNewTransBatch(globals []*storage.TransGlobalStore, branches [][]storage.TransBranchStore) []error {
+	docs := []interface{}{}
+	for i, g := range globals {
+		docs = append(docs, newTransDoc(g, branches[i]))
+	}
+	errs := make([]error, len(globals))
+	_, err := transColl().InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	var bwe mongo.BulkWriteException
+	if errors.As(err, &bwe) && bwe.WriteConcernError == nil {
+		for _, we := range bwe.WriteErrors {
+			errs[we.Index] = dtmimp.If(mongo.IsDuplicateKeyError(we), storage.ErrUniqueConflict, we).(error)
+		}
+	} else if err != nil {
+		for i := range errs {
+			errs[i] = err
+		}
+	}
+	return errs
+}
+
+// ChangeGlobalStatus changes global trans status. finished trans will expire in FinishedDataExpire
+func (s *Store) ChangeGlobalStatus(global *storage.TransGlobalStore, newStatus string, updates []string, finished bool) {
+	old := global.Status
+	global.Status = newStatus
+	set := bson.M{"status": newStatus, "data": dtmimp.MustMarshalString(global)}
+	if finished {
+		set["expire_at"] = time.Now().Add(time.Duration(conf.Store.FinishedDataExpire) * time.Second)
+	}
+	r, err := transColl().UpdateOne(ctx, bson.M{"_id": global.Gid, "status": old}, bson.M{"$set": set})
+	dtmimp.E2P(err)
+	if r.MatchedCount == 0 {
+		dtmimp.E2P(storage.ErrNotFound)
+	}
+}
+
+// TouchCronTime updates cronTime
+func (s *Store) TouchCronTime(global *storage.TransGlobalStore, nextCronInterval int64, nextCronTime *time.Time) {
+	global.UpdateTime = dtmutil.GetNextTime(0)
+	global.NextCronTime = nextCronTime
+	global.NextCronInterval = nextCronInterval
+	r, err := transColl().UpdateOne(ctx, bson.M{"_id": global.Gid, "status": global.Status}, bson.M{"$set": bson.M{
+		"next_cron_time":     *nextCronTime,
+		"next_cron_interval": nextCronInterval,
+		"data":               dtmimp.MustMarshalString(global),
+	}})
+	dtmimp.E2P(err)
+	if r.MatchedCount == 0 {
+		dtmimp.E2P(storage.ErrNotFound)
+	}
+}
+
+// LockOneGlobalTrans finds GlobalTrans, and leases it to a new owner for CronLeaseInterval
+func (s *Store) LockOneGlobalTrans(expireIn time.Duration) *storage.TransGlobalStore {
+	globals := s.LockGlobalTransBatch(expireIn, 1, storage.ShardFilter{})
+	if len(globals) == 0 {
+		return nil
+	}
+	return globals[0]
+}
+
+// LockGlobalTransBatch finds at most limit GlobalTrans in shards, and leases them to a new owner for CronLeaseInterval.
+// every trans is locked by an atomic findAndModify
+func (s *Store) LockGlobalTransBatch(expireIn time.Duration, limit int64, shards storage.ShardFilter) []*storage.TransGlobalStore {
+	globals := []*storage.TransGlobalStore{}
+	if shards.Total > 0 && len(shards.Owned) == 0 { // an empty $or is rejected by mongo
+		return globals
+	}
+	filter := bson.M{"status": unfinished, "next_cron_time": bson.M{"$lt": time.Now().Add(expireIn)}}
+	if shards.Total > 0 {
+		ors := bson.A{}
+		for _, shard := range shards.Owned {
+			ors = append(ors, bson.M{"shard": bson.M{"$mod": bson.A{shards.Total, shard}}})
+		}
+		filter["$or"] = ors
+	}
+	owner := shortuuid.New()
+	update := bson.M{"$set": bson.M{
+		"owner":          owner,
+		"next_cron_time": time.Now().Add(time.Duration(conf.CronLeaseInterval) * time.Second),
+	}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.M{"next_cron_time": 1}).
+		SetProjection(bson.M{"branches": 0}).
+		SetReturnDocument(options.After)
+	locked := []string{} // the locked trans may still match the filter if expireIn is longer than the lease
+	for int64(len(globals)) < limit {
+		filter["_id"] = bson.M{"$nin": locked}
+		doc := transDoc{}
+		err := transColl().FindOneAndUpdate(ctx, filter, update, opts).Decode(&doc)
+		if err == mongo.ErrNoDocuments {
+			break
+		}
+		dtmimp.E2P(err)
+		globals = append(globals, doc.toGlobal())
+		locked = append(locked, doc.Gid)
+	}
+	return globals
+}
+
+// RenewLease extends the lease of owner for another CronLeaseInterval
+func (s *Store) RenewLease(gid string, owner string) error {
+	r, err := transColl().UpdateOne(ctx, bson.M{"_id": gid, "owner": owner, "status": unfinished}, bson.M{"$set": bson.M{
+		"next_cron_time": time.Now().Add(time.Duration(conf.CronLeaseInterval) * time.Second),
+	}})
+	if err == nil && r.MatchedCount == 0 {
+		err = storage.ErrNotFound
+	}
+	return err
+}
+
+// ReleaseLease releases the lease of global.Owner, and saves the next cron time of global
+func (s *Store) ReleaseLease(global *storage.TransGlobalStore) {
+	owner := global.Owner
+	global.UpdateTime = dtmutil.GetNextTime(0)
+	global.Owner = ""
+	filter := bson.M{"_id": global.Gid, "owner": owner, "status": bson.M{"$eq": global.Status, "$in": unfinished["$in"]}}
+	_, err := transColl().UpdateOne(ctx, filter, bson.M{"$set": bson.M{
+		"owner":              "",
+		"next_cron_time":     *global.NextCronTime,
+		"next_cron_interval": global.NextCronInterval,
+		"data":               dtmimp.MustMarshalString(global),
+	}})
+	dtmimp.E2P(err)
+}
+
+// ResetCronTime rest nextCronTime
+// Prevent multiple backoff from causing NextCronTime to be too long
+func (s *Store) ResetCronTime(timeout time.Duration, limit int64) (succeedCount int64, hasRemaining bool, err error) {
+	filter := bson.M{"status": unfinished, "next_cron_time": bson.M{"$gt": time.Now().Add(timeout)}}
+	cursor, err := transColl().Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}).SetLimit(limit+1))
+	if err != nil {
+		return 0, false, err
+	}
+	docs := []transDoc{}
+	if err = cursor.All(ctx, &docs); err != nil {
+		return 0, false, err
+	}
+	hasRemaining = int64(len(docs)) > limit
+	gids := []string{}
+	for i := 0; i < len(docs) && int64(i) < limit; i++ {
+		gids = append(gids, docs[i].Gid)
+	}
+	filter["_id"] = bson.M{"$in": gids}
+	r, err := transColl().UpdateMany(ctx, filter, bson.M{"$set": bson.M{"next_cron_time": time.Now()}})
+	if err != nil {
+		return 0, false, err
+	}
+	return r.ModifiedCount, hasRemaining, nil
+}
+
+// KeepAliveInstance keeps instance alive for expireIn
+func (s *Store) KeepAliveInstance(instance string, expireIn time.Duration) {
+	_, err := instanceColl().UpdateOne(ctx, bson.M{"_id": instance}, bson.M{"$set": bson.M{"expire_at": time.Now().Add(expireIn)}},
+		options.Update().SetUpsert(true))
+	dtmimp.E2P(err)
+}
+
+// ListInstances lists alive instances. the ttl monitor of mongo runs every 60 seconds, so expire_at is checked
+func (s *Store) ListInstances() []string {
+	cursor, err := instanceColl().Find(ctx, bson.M{"expire_at": bson.M{"$gt": time.Now()}}, options.Find().SetSort(bson.M{"_id": 1}))
+	dtmimp.E2P(err)
+	docs := []instanceDoc{}
+	dtmimp.E2P(cursor.All(ctx, &docs))
+	instances := []string{}
+	for _, d := range docs {
+		instances = append(instances, d.Instance)
+	}
+	return instances
+}
+
+var (
+	client     *mongo.Client
+	clientOnce sync.Once
+)
+
+func mongoGet() *mongo.Client {
+	clientOnce.Do(func() {
+		uri := fmt.Sprintf("mongodb://%s:%d/?retryWrites=false", conf.Store.Host, conf.Store.Port)
+		opts := options.Client().ApplyURI(uri)
+		if conf.Store.User != "" {
+			opts.SetAuth(options.Credential{Username: conf.Store.User, Password: conf.Store.Password})
+		}
+		logger.Infof("connecting to mongo: %s", uri)
+		c, err := mongo.Connect(ctx, opts)
+		dtmimp.E2P(err)
+		client = c
+	})
+	return client
+}
+
+// collection returns the collection of name like "dtm.trans_global"
+func collection(name string) *mongo.Collection {
+	fs := strings.Split(name, ".")
+	return mongoGet().Database(fs[0]).Collection(fs[1])
+}
+
+func transColl() *mongo.Collection {
+	return collection(conf.Store.TransGlobalTable)
+}
+
+func instanceColl() *mongo.Collection {
+	return collection(conf.Store.CronInstanceTable)
+}
@@ -0,0 +1,39 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package mongo
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/dtm-labs/dtm/dtmsvr/config"
+	"github.com/dtm-labs/dtm/dtmsvr/storage/storetest"
+)
+
+// TestConformance runs the conformance tests against the mongo on localhost:27017, like the one of CI.
+// the trans are saved in the database dtm_storetest, so the other tests on the same mongo are not affected
+func TestConformance(t *testing.T) {
+	config.MustLoadConfig("")
+	conf.Store.Driver = config.Mongo
+	conf.Store.Host = "localhost"
+	conf.Store.Port = 27017
+	conf.Store.User = ""
+	conf.Store.Password = ""
+	conf.Store.TransGlobalTable = "dtm_storetest.trans_global"
+	conf.Store.CronInstanceTable = "dtm_storetest.cron_instance"
+	conn, err := net.DialTimeout("tcp", fmt.Sprintf("%s:%d", conf.Store.Host, conf.Store.Port), time.Second)
+	if err != nil {
+		t.Skipf("mongo is not reachable: %v", err)
+	}
+	conn.Close()
+
+	s := &Store{}
+	s.PopulateData(false)
+	storetest.Run(t, s)
+}
@@ -10,10 +10,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"hash/crc32"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"github.com/lithammer/shortuuid/v3"
 
 	"github.com/dtm-labs/dtm/dtmcli/dtmimp"
 	"github.com/dtm-labs/dtm/dtmcli/logger"
@@ -25,87 +30,158 @@ import (
 // TODO: optimize this, it's very strange to use pointer to dtmutil.Config
 var conf = &config.Config
 
-// TODO: optimize this, all function should have context as first parameter
-var ctx = context.Background()
-
-// Store is the storage with redis, all transaction information will bachend with redis
+// Store implements storage.StoreV2, and is the storage with redis, all transaction information will bachend with redis
 type Store struct {
 }
 
-// Ping execs ping cmd to redis
-func (s *Store) Ping() error {
+// PingContext execs ping cmd to redis
+func (s *Store) PingContext(ctx context.Context) error {
 	_, err := redisGet().Ping(ctx).Result()
 	return err
 }
 
-// PopulateData populates data to redis
-func (s *Store) PopulateData(skipDrop bool) {
+// PopulateDataContext populates data to redis
+func (s *Store) PopulateDataContext(ctx context.Context, skipDrop bool) error {
 	if !skipDrop {
-		_, err := redisGet().FlushAll(ctx).Result()
+		err := forEachMaster(ctx, func(ctx context.Context, c *redis.Client) error {
+			return c.FlushAll(ctx).Err()
+		})
 		logger.Infof("call redis flushall. result: %v", err)
-		dtmimp.PanicIf(err != nil, err)
+		return err
 	}
+	return nil
 }
 
-// FindTransGlobalStore finds GlobalTrans data by gid
-func (s *Store) FindTransGlobalStore(gid string) *storage.TransGlobalStore {
+// FindTransGlobalStoreContext finds GlobalTrans data by gid
+func (s *Store) FindTransGlobalStoreContext(ctx context.Context, gid string) (*storage.TransGlobalStore, error) {
 	logger.Debugf("calling FindTransGlobalStore: %s", gid)
-	r, err := redisGet().Get(ctx, conf.Store.RedisPrefix+"_g_"+gid).Result()
+	r, err := redisGet().Get(ctx, keyPrefix(gid)+"_g_"+gid).Result()
 	if err == redis.Nil {
-		return nil
+		return nil, storage.ErrNotFound
+	} else if err != nil {
+		return nil, err
 	}
-	dtmimp.E2P(err)
 	trans := &storage.TransGlobalStore{}
 	dtmimp.MustUnmarshalString(r, trans)
-	return trans
+	return trans, nil
 }
 
-// ScanTransGlobalStores lists GlobalTrans data
-func (s *Store) ScanTransGlobalStores(position *string, limit int64) []storage.TransGlobalStore {
+// ScanTransGlobalStoresContext lists GlobalTrans data
+func (s *Store) ScanTransGlobalStoresContext(ctx context.Context, position *string, limit int64) ([]storage.TransGlobalStore, error) {
 	logger.Debugf("calling ScanTransGlobalStores: %s %d", *position, limit)
-	lid := uint64(0)
+	nodes, err := scanNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// position is the cursor of the node, prefixed by the index of the node if there are many nodes
+	node, lid := uint64(0), uint64(0)
 	if *position != "" {
-		lid = uint64(dtmimp.MustAtoi(*position))
+		pos := strings.Split(*position, "/")
+		if len(pos) == 2 {
+			if node, err = strconv.ParseUint(pos[0], 10, 64); err != nil || node >= uint64(len(nodes)) {
+				return nil, fmt.Errorf("invalid position: %s", *position)
+			}
+		}
+		if lid, err = strconv.ParseUint(pos[len(pos)-1], 10, 64); err != nil {
+			return nil, err
+		}
+	}
+	pattern := conf.Store.RedisPrefix + "_g_*"
+	if conf.Store.RedisKeyShards > 0 {
+		pattern = conf.Store.RedisPrefix + "{*}_g_*"
+	}
+	keys, cursor, err := nodes[node].Scan(ctx, lid, pattern, limit).Result()
+	if err != nil {
+		return nil, err
+	}
+	values, err := getValues(ctx, keys)
+	if err != nil {
+		return nil, err
 	}
-	keys, cursor, err := redisGet().Scan(ctx, lid, conf.Store.RedisPrefix+"_g_*", limit).Result()
-	dtmimp.E2P(err)
 	globals := []storage.TransGlobalStore{}
-	if len(keys) > 0 {
-		values, err := redisGet().MGet(ctx, keys...).Result()
-		dtmimp.E2P(err)
-		for _, v := range values {
-			global := storage.TransGlobalStore{}
-			dtmimp.MustUnmarshalString(v.(string), &global)
-			globals = append(globals, global)
-		}
+	for _, v := range values {
+		global := storage.TransGlobalStore{}
+		dtmimp.MustUnmarshalString(v, &global)
+		globals = append(globals, global)
 	}
-	if cursor > 0 {
-		*position = fmt.Sprintf("%d", cursor)
-	} else {
+	if cursor == 0 && node+1 < uint64(len(nodes)) {
+		*position = fmt.Sprintf("%d/0", node+1)
+	} else if cursor == 0 {
 		*position = ""
+	} else if len(nodes) > 1 {
+		*position = fmt.Sprintf("%d/%d", node, cursor)
+	} else {
+		*position = fmt.Sprintf("%d", cursor)
 	}
-	return globals
+	return globals, nil
 }
 
-// FindBranches finds Branch data by gid
-func (s *Store) FindBranches(gid string) []storage.TransBranchStore {
+// FindBranchesContext finds Branch data by gid
+func (s *Store) FindBranchesContext(ctx context.Context, gid string) ([]storage.TransBranchStore, error) {
 	logger.Debugf("calling FindBranches: %s", gid)
-	sa, err := redisGet().LRange(ctx, conf.Store.RedisPrefix+"_b_"+gid, 0, -1).Result()
-	dtmimp.E2P(err)
+	sa, err := redisGet().LRange(ctx, keyPrefix(gid)+"_b_"+gid, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
 	branches := make([]storage.TransBranchStore, len(sa))
 	for k, v := range sa {
 		dtmimp.MustUnmarshalString(v, &branches[k])
 	}
-	return branches
+	return branches, nil
 }
 
-// UpdateBranches updates branches info
-func (s *Store) UpdateBranches(branches []storage.TransBranchStore, updates []string) (int, error) {
-	return 0, nil // not implemented
+// UpdateBranchesContext updates branches info.
+// the branches of a gid are merged in memory, and saved if they are not changed by others, or merged again
+func (s *Store) UpdateBranchesContext(ctx context.Context, branches []storage.TransBranchStore, updates []string) (int, error) {
+	updated := 0
+	for _, gid := range storage.BranchGids(branches) {
+		for {
+			values, err := redisGet().LRange(ctx, keyPrefix(gid)+"_b_"+gid, 0, -1).Result()
+			if err != nil {
+				return updated, err
+			}
+			saved := make([]storage.TransBranchStore, len(values))
+			for i, v := range values {
+				dtmimp.MustUnmarshalString(v, &saved[i])
+			}
+			changed, err := storage.MergeBranchUpdates(saved, branches, updates)
+			if err != nil {
+				return updated, err
+			} else if len(changed) == 0 {
+				break
+			}
+			args := newArgList().AppendGid(gid)
+			for _, i := range changed {
+				args.AppendRaw(i).AppendRaw(values[i]).AppendObject(saved[i])
+			}
+			logger.Debugf("calling lua. args: %v\nlua:%s", args, luaUpdateBranches)
+			n, err := redisGet().Eval(ctx, luaUpdateBranches, args.Keys, args.List...).Int()
+			if err != nil {
+				return updated, err
+			}
+			if n == len(changed) {
+				updated += n
+				break
+			}
+		}
+	}
+	return updated, nil
 }
 
+const luaUpdateBranches = `-- UpdateBranches
+for k = 3, #ARGV, 3 do
+	if redis.call('LINDEX', KEYS[2], ARGV[k]) ~= ARGV[k+1] then
+		return 0
+	end
+end
+for k = 3, #ARGV, 3 do
+	redis.call('LSET', KEYS[2], ARGV[k], ARGV[k+2])
+end
+return (#ARGV - 2) / 3
+`
+
 type argList struct {
-	Keys []string      // 1 global trans, 2 branches, 3 indices, 4 status
+	Keys []string      // 1 global trans, 2 branches, 3 indices, 4 status, 5 lease if needed
 	List []interface{} // 1 redis prefix, 2 data expire
 }
 
@@ -114,11 +190,21 @@ func newArgList() *argList {
 	return a.AppendRaw(conf.Store.RedisPrefix).AppendObject(conf.Store.DataExpire)
 }
 
+// AppendGid appends the keys of gid, which are in the slot of keyPrefix(gid)
 func (a *argList) AppendGid(gid string) *argList {
-	a.Keys = append(a.Keys, conf.Store.RedisPrefix+"_g_"+gid)
-	a.Keys = append(a.Keys, conf.Store.RedisPrefix+"_b_"+gid)
-	a.Keys = append(a.Keys, conf.Store.RedisPrefix+"_u")
-	a.Keys = append(a.Keys, conf.Store.RedisPrefix+"_s_"+gid)
+	return a.appendKeys(keyPrefix(gid), gid)
+}
+
+// AppendShard appends the keys of the shard with prefix, of which only the index of cron time is used
+func (a *argList) AppendShard(prefix string) *argList {
+	return a.appendKeys(prefix, "")
+}
+
+func (a *argList) appendKeys(prefix string, gid string) *argList {
+	a.Keys = append(a.Keys, prefix+"_g_"+gid)
+	a.Keys = append(a.Keys, prefix+"_b_"+gid)
+	a.Keys = append(a.Keys, prefix+"_u")
+	a.Keys = append(a.Keys, prefix+"_s_"+gid)
 	return a
 }
 
@@ -151,14 +237,13 @@ func handleRedisResult(ret interface{}, err error) (string, error) {
 	return s, err
 }
 
-func callLua(a *argList, lua string) (string, error) {
+func callLua(ctx context.Context, a *argList, lua string) (string, error) {
 	logger.Debugf("calling lua. args: %v\nlua:%s", a, lua)
 	ret, err := redisGet().Eval(ctx, lua, a.Keys, a.List...).Result()
 	return handleRedisResult(ret, err)
 }
 
-// MaySaveNewTrans creates a new trans
-func (s *Store) MaySaveNewTrans(global *storage.TransGlobalStore, branches []storage.TransBranchStore) error {
+func newMaySaveArgs(global *storage.TransGlobalStore, branches []storage.TransBranchStore) *argList {
 	a := newArgList().
 		AppendGid(global.Gid).
 		AppendObject(global).
@@ -168,7 +253,10 @@ func (s *Store) MaySaveNewTrans(global *storage.TransGlobalStore, branches []sto
 		AppendBranches(branches)
 	global.Steps = nil
 	global.Payloads = nil
-	_, err := callLua(a, `-- MaySaveNewTrans
+	return a
+}
+
+const luaMaySaveNewTrans = `-- MaySaveNewTrans
 local g = redis.call('GET', KEYS[1])
 if g ~= false then
 	return 'UNIQUE_CONFLICT'
@@ -181,37 +269,60 @@ for k = 7, table.getn(ARGV) do
 	redis.call('RPUSH', KEYS[2], ARGV[k])
 end
 redis.call('EXPIRE', KEYS[2], ARGV[2])
-`)
+`
+
+// MaySaveNewTransContext creates a new trans
+func (s *Store) MaySaveNewTransContext(ctx context.Context, global *storage.TransGlobalStore, branches []storage.TransBranchStore) error {
+	_, err := callLua(ctx, newMaySaveArgs(global, branches), luaMaySaveNewTrans)
 	return err
 }
 
-// LockGlobalSaveBranches creates branches
-func (s *Store) LockGlobalSaveBranches(gid string, status string, branches []storage.TransBranchStore, branchStart int) {
+// MaySaveNewTransBatchContext creates many trans, the lua scripts are sent in one pipeline
+func (s *Store) MaySaveNewTransBatchContext(ctx context.Context, globals []*storage.TransGlobalStore, branches [][]storage.TransBranchStore) []error {
+	cmds := make([]*redis.Cmd, len(globals))
+	_, _ = redisGet().Pipelined(ctx, func(p redis.Pipeliner) error {
+		for i, g := range globals {
+			a := newMaySaveArgs(g, branches[i])
+			cmds[i] = p.Eval(ctx, luaMaySaveNewTrans, a.Keys, a.List...)
+		}
+		return nil
+	})
+	errs := make([]error, len(globals))
+	for i, cmd := range cmds {
+		_, errs[i] = handleRedisResult(cmd.Result())
+	}
+	return errs
+}
+
+// LockGlobalSaveBranchesContext creates branches
+func (s *Store) LockGlobalSaveBranchesContext(ctx context.Context, gid string, status string, branches []storage.TransBranchStore, branchStart int) error {
 	args := newArgList().
 		AppendGid(gid).
 		AppendRaw(status).
 		AppendRaw(branchStart).
+		AppendRaw(storage.OwnerOf(ctx)).
 		AppendBranches(branches)
-	_, err := callLua(args, `-- LockGlobalSaveBranches
+	args.Keys = append(args.Keys, keyPrefix(gid)+"_l_"+gid)
+	_, err := callLua(ctx, args, `-- LockGlobalSaveBranches
 local old = redis.call('GET', KEYS[4])
-if old ~= ARGV[3] then
+if old ~= ARGV[3] or ARGV[5] ~= '' and redis.call('GET', KEYS[5]) ~= ARGV[5] then
 	return 'NOT_FOUND'
 end
 local start = ARGV[4]
-for k = 5, table.getn(ARGV) do
+for k = 6, table.getn(ARGV) do
 	if start == "-1" then
 		redis.call('RPUSH', KEYS[2], ARGV[k])
 	else
-		redis.call('LSET', KEYS[2], start+k-5, ARGV[k])
+		redis.call('LSET', KEYS[2], start+k-6, ARGV[k])
 	end
 end
 redis.call('EXPIRE', KEYS[2], ARGV[2])
 	`)
-	dtmimp.E2P(err)
+	return err
 }
 
-// ChangeGlobalStatus changes global trans status
-func (s *Store) ChangeGlobalStatus(global *storage.TransGlobalStore, newStatus string, updates []string, finished bool) {
+// ChangeGlobalStatusContext changes global trans status
+func (s *Store) ChangeGlobalStatusContext(ctx context.Context, global *storage.TransGlobalStore, newStatus string, updates []string, finished bool) error {
 	old := global.Status
 	global.Status = newStatus
 	args := newArgList().
@@ -221,10 +332,12 @@ func (s *Store) ChangeGlobalStatus(global *storage.TransGlobalStore, newStatus s
 		AppendRaw(finished).
 		AppendRaw(global.Gid).
 		AppendRaw(newStatus).
-		AppendObject(conf.Store.FinishedDataExpire)
-	_, err := callLua(args, `-- ChangeGlobalStatus
+		AppendObject(conf.Store.FinishedDataExpire).
+		AppendRaw(storage.OwnerOf(ctx))
+	args.Keys = append(args.Keys, keyPrefix(global.Gid)+"_l_"+global.Gid)
+	_, err := callLua(ctx, args, `-- ChangeGlobalStatus
 local old = redis.call('GET', KEYS[4])
-if old ~= ARGV[4] then
+if old ~= ARGV[4] or ARGV[9] ~= '' and redis.call('GET', KEYS[5]) ~= ARGV[9] then
   return 'NOT_FOUND'
 end
 redis.call('SET', KEYS[1],  ARGV[3], 'EX', ARGV[2])
@@ -236,46 +349,224 @@ if ARGV[5] == '1' then
 	redis.call('EXPIRE', KEYS[4], ARGV[8])
 end
 `)
-	dtmimp.E2P(err)
+	return err
+}
+
+// LockOneGlobalTransContext finds GlobalTrans, and leases it to a new owner for CronLeaseInterval
+func (s *Store) LockOneGlobalTransContext(ctx context.Context, expireIn time.Duration) (*storage.TransGlobalStore, error) {
+	for {
+		globals, locked, err := s.lockGlobalTrans(ctx, expireIn, 1, storage.ShardFilter{})
+		if err != nil || locked == 0 {
+			return nil, err
+		} else if len(globals) > 0 {
+			return globals[0], nil
+		}
+	}
 }
 
-// LockOneGlobalTrans finds GlobalTrans
-func (s *Store) LockOneGlobalTrans(expireIn time.Duration) *storage.TransGlobalStore {
+// LockGlobalTransBatchContext finds at most limit GlobalTrans in shards, and leases them to a new owner for CronLeaseInterval
+func (s *Store) LockGlobalTransBatchContext(ctx context.Context, expireIn time.Duration, limit int64, shards storage.ShardFilter) ([]*storage.TransGlobalStore, error) {
+	globals, _, err := s.lockGlobalTrans(ctx, expireIn, limit, shards)
+	return globals, err
+}
+
+// lockGlobalTrans returns the locked trans, and the count of locked gids, some of which may have expired.
+// the candidates of all key shards are merged by next cron time, and then locked in their own shards
+func (s *Store) lockGlobalTrans(ctx context.Context, expireIn time.Duration, limit int64, shards storage.ShardFilter) ([]*storage.TransGlobalStore, int, error) {
 	expired := time.Now().Add(expireIn).Unix()
-	next := time.Now().Add(time.Duration(conf.RetryInterval) * time.Second).Unix()
-	args := newArgList().AppendGid("").AppendRaw(expired).AppendRaw(next)
-	lua := `-- LockOneGlobalTrans
-local r = redis.call('ZRANGE', KEYS[3], 0, 0, 'WITHSCORES')
-local gid = r[1]
-if gid == nil then
-	return 'NOT_FOUND'
+	candidates, err := findCronCandidates(ctx, expired, limit, shards)
+	globals := []*storage.TransGlobalStore{}
+	if err != nil || len(candidates) == 0 {
+		return globals, 0, err
+	}
+	gidsOf := map[string][]string{}
+	prefixes := []string{}
+	for _, c := range candidates {
+		if len(gidsOf[c.prefix]) == 0 {
+			prefixes = append(prefixes, c.prefix)
+		}
+		gidsOf[c.prefix] = append(gidsOf[c.prefix], c.gid)
+	}
+	next := time.Now().Add(time.Duration(conf.CronLeaseInterval) * time.Second).Unix()
+	owner := shortuuid.New()
+	locked := 0
+	for _, prefix := range prefixes {
+		args := newArgList().AppendShard(prefix).AppendRaw(expired).AppendRaw(next).
+			AppendRaw(owner).AppendRaw(conf.CronLeaseInterval)
+		for _, gid := range gidsOf[prefix] {
+			args.AppendRaw(gid)
+			args.Keys = append(args.Keys, prefix+"_l_"+gid) // the lease key of ARGV[i] is KEYS[i-2]
+		}
+		// candidates may have been locked by others, so the cron time is checked again
+		lua := `-- LockGlobalTrans
+local locked = {}
+for i = 7, #ARGV do
+	local score = redis.call('ZSCORE', KEYS[3], ARGV[i])
+	if score and tonumber(score) <= tonumber(ARGV[3]) then
+		redis.call('ZADD', KEYS[3], ARGV[4], ARGV[i])
+		redis.call('SET', KEYS[i-2], ARGV[5], 'EX', ARGV[6])
+		table.insert(locked, ARGV[i])
+	end
 end
+return locked
+`
+		logger.Debugf("calling lua. args: %v\nlua:%s", args, lua)
+		r, err := redisGet().Eval(ctx, lua, args.Keys, args.List...).StringSlice()
+		if err != nil {
+			return globals, locked, err
+		}
+		locked += len(r)
+		keys := []string{}
+		for _, gid := range r {
+			keys = append(keys, prefix+"_g_"+gid)
+		}
+		values, err := getValues(ctx, keys)
+		if err != nil {
+			return globals, locked, err
+		}
+		for _, v := range values {
+			global := &storage.TransGlobalStore{}
+			dtmimp.MustUnmarshalString(v, global)
+			global.Owner = owner
+			globals = append(globals, global)
+		}
+	}
+	return globals, locked, nil
+}
 
-if tonumber(r[2]) > tonumber(ARGV[3]) then
-	return 'NOT_FOUND'
+type cronCandidate struct {
+	prefix   string
+	gid      string
+	cronTime int64
+}
+
+// cronCursors are the offsets in the cron time index of every key shard, where the next scan of candidates starts
+var cronCursors = struct {
+	sync.Mutex
+	offsets map[string]int64
+}{offsets: map[string]int64{}}
+
+// findCronCandidates finds at most limit gids in shards, whose cron time is before expired, in the order of cron time
+func findCronCandidates(ctx context.Context, expired int64, limit int64, shards storage.ShardFilter) ([]cronCandidate, error) {
+	// the shard of a gid is the hash of gid modulo total shards. candidates are scanned in pages of limit * total shards.
+	// at most 2 pages are scanned in one call, to avoid blocking redis. the offset after them is returned first,
+	// and the next call continues from it, or from the start after the end is reached
+	lua := `-- FindCronCandidates
+local limit = tonumber(ARGV[4])
+local total = tonumber(ARGV[5])
+local offset = tonumber(ARGV[6])
+local owned = {}
+for i = 7, #ARGV do
+	owned[tonumber(ARGV[i])] = true
 end
-redis.call('ZADD', KEYS[3], ARGV[4], gid)
-return gid
+local found = {'0'}
+local page = limit * math.max(total, 1)
+for pages = 1, 2 do
+	local r = redis.call('ZRANGEBYSCORE', KEYS[3], '-inf', ARGV[3], 'WITHSCORES', 'LIMIT', offset, page)
+	for i = 1, #r, 2 do
+		if #found < limit * 2 + 1 and (total == 0 or owned[tonumber(string.sub(redis.sha1hex(r[i]), 1, 8), 16) % total]) then
+			table.insert(found, r[i])
+			table.insert(found, r[i+1])
+		end
+	end
+	if #found >= limit * 2 + 1 then -- the found ones leave the page after locked, so the page is scanned again
+		found[1] = tostring(offset)
+		break
+	elseif #r < page * 2 then
+		found[1] = '0'
+		break
+	end
+	offset = offset + page
+	found[1] = tostring(offset)
+end
+return found
 `
-	for {
-		r, err := callLua(args, lua)
-		if errors.Is(err, storage.ErrNotFound) {
-			return nil
+	candidates := []cronCandidate{}
+	for _, prefix := range shardPrefixes() {
+		cronCursors.Lock()
+		offset := cronCursors.offsets[prefix]
+		cronCursors.Unlock()
+		args := newArgList().AppendShard(prefix).AppendRaw(expired).AppendRaw(limit).AppendRaw(shards.Total).AppendRaw(offset)
+		for _, shard := range shards.Owned {
+			args.AppendRaw(shard)
 		}
-		dtmimp.E2P(err)
-		global := s.FindTransGlobalStore(r)
-		if global != nil {
-			return global
+		logger.Debugf("calling lua. args: %v\nlua:%s", args, lua)
+		r, err := redisGet().Eval(ctx, lua, args.Keys, args.List...).StringSlice()
+		if err != nil {
+			return nil, err
 		}
+		cronCursors.Lock()
+		cronCursors.offsets[prefix] = int64(dtmimp.MustAtoi(r[0]))
+		cronCursors.Unlock()
+		for i := 1; i+1 < len(r); i += 2 {
+			cronTime, err := strconv.ParseFloat(r[i+1], 64)
+			if err != nil {
+				return nil, err
+			}
+			candidates = append(candidates, cronCandidate{prefix: prefix, gid: r[i], cronTime: int64(cronTime)})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].cronTime < candidates[j].cronTime
+	})
+	if int64(len(candidates)) > limit {
+		candidates = candidates[:limit]
 	}
+	return candidates, nil
+}
+
+// RenewLeaseContext extends the lease of owner for another CronLeaseInterval
+func (s *Store) RenewLeaseContext(ctx context.Context, gid string, owner string) error {
+	next := time.Now().Add(time.Duration(conf.CronLeaseInterval) * time.Second).Unix()
+	args := newArgList().AppendGid(gid).AppendRaw(next).AppendRaw(owner).AppendRaw(conf.CronLeaseInterval).AppendRaw(gid)
+	args.Keys = append(args.Keys, keyPrefix(gid)+"_l_"+gid)
+	_, err := callLua(ctx, args, `-- RenewLease
+local st = redis.call('GET', KEYS[4])
+if redis.call('GET', KEYS[5]) ~= ARGV[4] or (st ~= 'prepared' and st ~= 'aborting' and st ~= 'submitted') then
+	return 'NOT_FOUND'
+end
+redis.call('ZADD', KEYS[3], ARGV[3], ARGV[6])
+redis.call('SET', KEYS[5], ARGV[4], 'EX', ARGV[5])
+`)
+	return err
 }
 
-// ResetCronTime rest nextCronTime
+// ReleaseLeaseContext releases the lease of global.Owner, and saves the next cron time of global
+func (s *Store) ReleaseLeaseContext(ctx context.Context, global *storage.TransGlobalStore) error {
+	owner := global.Owner
+	global.UpdateTime = dtmutil.GetNextTime(0)
+	global.Owner = ""
+	args := newArgList().
+		AppendGid(global.Gid).
+		AppendObject(global).
+		AppendRaw(global.NextCronTime.Unix()).
+		AppendRaw(global.Status).
+		AppendRaw(global.Gid).
+		AppendRaw(owner)
+	args.Keys = append(args.Keys, keyPrefix(global.Gid)+"_l_"+global.Gid)
+	_, err := callLua(ctx, args, `-- ReleaseLease
+if redis.call('GET', KEYS[5]) ~= ARGV[7] then
+	return 'NOT_FOUND'
+end
+redis.call('DEL', KEYS[5])
+local st = redis.call('GET', KEYS[4])
+if st ~= ARGV[5] or (st ~= 'prepared' and st ~= 'aborting' and st ~= 'submitted') then
+	return 'NOT_FOUND'
+end
+redis.call('ZADD', KEYS[3], ARGV[4], ARGV[6])
+redis.call('SET', KEYS[1], ARGV[3], 'EX', ARGV[2])
+`)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// ResetCronTimeContext rest nextCronTime
 // Prevent multiple backoff from causing NextCronTime to be too long
-func (s *Store) ResetCronTime(timeout time.Duration, limit int64) (succeedCount int64, hasRemaining bool, err error) {
+func (s *Store) ResetCronTimeContext(ctx context.Context, timeout time.Duration, limit int64) (succeedCount int64, hasRemaining bool, err error) {
 	next := time.Now().Unix()
 	timeoutTimestamp := time.Now().Add(timeout).Unix()
-	args := newArgList().AppendGid("").AppendRaw(timeoutTimestamp).AppendRaw(next).AppendRaw(limit)
 	lua := `-- ResetCronTime
 local r = redis.call('ZRANGEBYSCORE', KEYS[3], ARGV[3], '+inf', 'LIMIT', 0, ARGV[5]+1)
 local i = 0
@@ -289,19 +580,24 @@ for score,gid in pairs(r) do
 end
 return tostring(i)
 `
-	r := ""
-	r, err = callLua(args, lua)
-	dtmimp.E2P(err)
-	succeedCount = int64(dtmimp.MustAtoi(r))
-	if succeedCount > limit {
-		hasRemaining = true
-		succeedCount = limit
+	for _, prefix := range shardPrefixes() {
+		left := limit - succeedCount
+		args := newArgList().AppendShard(prefix).AppendRaw(timeoutTimestamp).AppendRaw(next).AppendRaw(left)
+		r, err := callLua(ctx, args, lua)
+		if err != nil {
+			return succeedCount, hasRemaining, err
+		}
+		count := int64(dtmimp.MustAtoi(r))
+		if count > left {
+			return limit, true, nil
+		}
+		succeedCount += count
 	}
-	return
+	return succeedCount, false, nil
 }
 
-// TouchCronTime updates cronTime
-func (s *Store) TouchCronTime(global *storage.TransGlobalStore, nextCronInterval int64, nextCronTime *time.Time) {
+// TouchCronTimeContext updates cronTime
+func (s *Store) TouchCronTimeContext(ctx context.Context, global *storage.TransGlobalStore, nextCronInterval int64, nextCronTime *time.Time) error {
 	global.UpdateTime = dtmutil.GetNextTime(0)
 	global.NextCronTime = nextCronTime
 	global.NextCronInterval = nextCronInterval
@@ -311,7 +607,7 @@ func (s *Store) TouchCronTime(global *storage.TransGlobalStore, nextCronInterval
 		AppendRaw(global.NextCronTime.Unix()).
 		AppendRaw(global.Status).
 		AppendRaw(global.Gid)
-	_, err := callLua(args, `-- TouchCronTime
+	_, err := callLua(ctx, args, `-- TouchCronTime
 local old = redis.call('GET', KEYS[4])
 if old ~= ARGV[5] then
 	return 'NOT_FOUND'
@@ -319,22 +615,154 @@ end
 redis.call('ZADD', KEYS[3], ARGV[4], ARGV[6])
 redis.call('SET', KEYS[1], ARGV[3], 'EX', ARGV[2])
 	`)
-	dtmimp.E2P(err)
+	return err
+}
+
+// KeepAliveInstanceContext registers instance as alive until expireIn later
+func (s *Store) KeepAliveInstanceContext(ctx context.Context, instance string, expireIn time.Duration) error {
+	return redisGet().HSet(ctx, conf.Store.RedisPrefix+"_i", instance, time.Now().Add(expireIn).Unix()).Err()
+}
+
+// ListInstancesContext lists alive instances, and removes the expired ones
+func (s *Store) ListInstancesContext(ctx context.Context) ([]string, error) {
+	args := newArgList().AppendRaw(time.Now().Unix())
+	args.Keys = append(args.Keys, conf.Store.RedisPrefix+"_i")
+	lua := `-- ListInstances
+local all = redis.call('HGETALL', KEYS[1])
+local alive = {}
+for i = 1, #all, 2 do
+	if tonumber(all[i+1]) > tonumber(ARGV[3]) then
+		table.insert(alive, all[i])
+	else
+		redis.call('HDEL', KEYS[1], all[i])
+	end
+end
+return alive
+`
+	logger.Debugf("calling lua. args: %v\nlua:%s", args, lua)
+	instances, err := redisGet().Eval(ctx, lua, args.Keys, args.List...).StringSlice()
+	sort.Strings(instances)
+	return instances, err
+}
+
+// keyPrefix returns the prefix of the keys of gid. the keys of a trans share the hash tag of the prefix, so they are in one slot
+func keyPrefix(gid string) string {
+	if conf.Store.RedisKeyShards <= 0 {
+		return conf.Store.RedisPrefix
+	}
+	return shardPrefix(int64(crc32.ChecksumIEEE([]byte(gid))) % conf.Store.RedisKeyShards)
+}
+
+func shardPrefix(shard int64) string {
+	return fmt.Sprintf("%s{%d}", conf.Store.RedisPrefix, shard)
+}
+
+// shardPrefixes returns the prefixes of all key shards, each of which has its own index of cron time
+func shardPrefixes() []string {
+	if conf.Store.RedisKeyShards <= 0 {
+		return []string{conf.Store.RedisPrefix}
+	}
+	prefixes := []string{}
+	for i := int64(0); i < conf.Store.RedisKeyShards; i++ {
+		prefixes = append(prefixes, shardPrefix(i))
+	}
+	return prefixes
+}
+
+// getValues gets the values of keys in a pipeline, so that the keys can be in different slots. missing keys are skipped
+func getValues(ctx context.Context, keys []string) ([]string, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	cmds := make([]*redis.StringCmd, len(keys))
+	_, err := redisGet().Pipelined(ctx, func(p redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = p.Get(ctx, key)
+		}
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+	values := []string{}
+	for _, cmd := range cmds {
+		if v, err := cmd.Result(); err == nil {
+			values = append(values, v)
+		} else if err != redis.Nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// scanNodes returns the nodes to scan for the keys of trans
+func scanNodes(ctx context.Context) ([]*redis.Client, error) {
+	cc, ok := redisGet().(*redis.ClusterClient)
+	if !ok {
+		return []*redis.Client{redisGet().(*redis.Client)}, nil
+	}
+	if conf.Store.RedisKeyShards <= 0 {
+		c, err := cc.MasterForKey(ctx, conf.Store.RedisPrefix)
+		return []*redis.Client{c}, err
+	}
+	nodes := []*redis.Client{}
+	mu := sync.Mutex{}
+	err := cc.ForEachMaster(ctx, func(ctx context.Context, c *redis.Client) error {
+		mu.Lock()
+		defer mu.Unlock()
+		nodes = append(nodes, c)
+		return nil
+	})
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Options().Addr < nodes[j].Options().Addr
+	})
+	return nodes, err
+}
+
+func forEachMaster(ctx context.Context, fn func(ctx context.Context, c *redis.Client) error) error {
+	if cc, ok := redisGet().(*redis.ClusterClient); ok {
+		return cc.ForEachMaster(ctx, fn)
+	}
+	return fn(ctx, redisGet().(*redis.Client))
+}
+
+// endpoints returns Endpoints of the config, or Host:Port if Endpoints is empty
+func endpoints() []string {
+	if conf.Store.Endpoints != "" {
+		return strings.Split(conf.Store.Endpoints, ",")
+	}
+	return []string{fmt.Sprintf("%s:%d", conf.Store.Host, conf.Store.Port)}
 }
 
 var (
-	rdb  *redis.Client
+	rdb  redis.UniversalClient
 	once sync.Once
 )
 
-func redisGet() *redis.Client {
+func redisGet() redis.UniversalClient {
 	once.Do(func() {
 		logger.Debugf("connecting to redis: %v", conf.Store)
-		rdb = redis.NewClient(&redis.Options{
-			Addr:     fmt.Sprintf("%s:%d", conf.Store.Host, conf.Store.Port),
-			Username: conf.Store.User,
-			Password: conf.Store.Password,
-		})
+		switch conf.Store.RedisMode {
+		case "cluster":
+			rdb = redis.NewClusterClient(&redis.ClusterOptions{
+				Addrs:    endpoints(),
+				Username: conf.Store.User,
+				Password: conf.Store.Password,
+			})
+		case "sentinel":
+			rdb = redis.NewFailoverClient(&redis.FailoverOptions{
+				MasterName:    conf.Store.RedisMasterName,
+				SentinelAddrs: endpoints(),
+				Username:      conf.Store.User,
+				Password:      conf.Store.Password,
+			})
+		default:
+			rdb = redis.NewClient(&redis.Options{
+				Addr:     endpoints()[0],
+				Username: conf.Store.User,
+				Password: conf.Store.Password,
+			})
+		}
 	})
 	return rdb
 }
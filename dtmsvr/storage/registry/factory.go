@@ -15,6 +15,11 @@ type SingletonFactory struct {
 	creatorFunction func() storage.Store
 }
 
+// NewSingletonFactory returns a factory, which creates the store by creator at the first call of GetStorage
+func NewSingletonFactory(creator func() storage.Store) *SingletonFactory {
+	return &SingletonFactory{creatorFunction: creator}
+}
+
 // GetStorage implement the StorageFactory.GetStorage
 func (f *SingletonFactory) GetStorage() storage.Store {
 	f.once.Do(func() {
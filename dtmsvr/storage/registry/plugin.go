@@ -0,0 +1,36 @@
+//go:build cgo && !dtm_noplugin
+// +build cgo,!dtm_noplugin
+
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package registry
+
+import (
+	"fmt"
+	"plugin"
+	"sync"
+
+	"github.com/dtm-labs/dtm/dtmcli/logger"
+)
+
+var loadedPlugins sync.Map
+
+// LoadPlugin opens the go plugin of path, whose init functions register the stores by Register.
+// the plugin should be built by `go build -buildmode=plugin` with the same version of dtm and go.
+// a plugin is opened only once. go plugins require cgo, so dtm built with CGO_ENABLED=0, like the released binaries,
+// or with the tag dtm_noplugin does not support plugins. link the stores by the blank imports in stores.go instead
+func LoadPlugin(path string) error {
+	if _, loaded := loadedPlugins.Load(path); loaded {
+		return nil
+	}
+	logger.Infof("loading store plugin: %s", path)
+	if _, err := plugin.Open(path); err != nil {
+		return fmt.Errorf("load store plugin %s failed: %w", path, err)
+	}
+	loadedPlugins.Store(path, true)
+	return nil
+}
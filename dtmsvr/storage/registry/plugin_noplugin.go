@@ -0,0 +1,19 @@
+//go:build !cgo || dtm_noplugin
+// +build !cgo dtm_noplugin
+
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package registry
+
+import "fmt"
+
+// LoadPlugin returns an error, because dtm is built without cgo, or with the tag dtm_noplugin.
+// the stores can still be registered by the packages linked into dtm, see stores.go
+func LoadPlugin(path string) error {
+	return fmt.Errorf("load store plugin %s failed: dtm is built without cgo or with the tag dtm_noplugin. "+
+		"build dtm with CGO_ENABLED=1, or link the store into dtm by the tag dtm_stores", path)
+}
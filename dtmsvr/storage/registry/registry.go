@@ -1,11 +1,18 @@
 package registry
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/dtm-labs/dtm/dtmcli/dtmimp"
 	"github.com/dtm-labs/dtm/dtmsvr/config"
 	"github.com/dtm-labs/dtm/dtmsvr/storage"
 	"github.com/dtm-labs/dtm/dtmsvr/storage/boltdb"
+	"github.com/dtm-labs/dtm/dtmsvr/storage/etcd"
+	"github.com/dtm-labs/dtm/dtmsvr/storage/mongo"
 	"github.com/dtm-labs/dtm/dtmsvr/storage/redis"
 	"github.com/dtm-labs/dtm/dtmsvr/storage/sql"
 )
@@ -20,28 +27,85 @@ type StorageFactory interface {
 
 var sqlFac = &SingletonFactory{
 	creatorFunction: func() storage.Store {
-		return &sql.Store{}
+		return storage.AsStore(&sql.Store{})
 	},
 }
 
 var storeFactorys = map[string]StorageFactory{
 	"boltdb": &SingletonFactory{
 		creatorFunction: func() storage.Store {
-			return boltdb.NewStore(conf.Store.DataExpire, conf.RetryInterval)
+			return storage.AsStore(boltdb.NewStore(conf.Store.DataExpire, conf.CronLeaseInterval))
 		},
 	},
 	"redis": &SingletonFactory{
 		creatorFunction: func() storage.Store {
-			return &redis.Store{}
+			return storage.AsStore(&redis.Store{})
+		},
+	},
+	"mongo": &SingletonFactory{
+		creatorFunction: func() storage.Store {
+			return &mongo.Store{}
+		},
+	},
+	"etcd": &SingletonFactory{
+		creatorFunction: func() storage.Store {
+			return &etcd.Store{}
 		},
 	},
 	"mysql":    sqlFac,
 	"postgres": sqlFac,
+	"sqlite":   sqlFac,
+}
+
+var factorysMu sync.RWMutex
+
+// Register makes a store available by the driver name in config.Store.Driver.
+// a third-party store calls it in the init function of its package, which is linked into dtm by a plugin in config.Store.Plugins.
+// it panics if Register is called twice with the same name or if factory is nil
+func Register(name string, factory StorageFactory) {
+	factorysMu.Lock()
+	defer factorysMu.Unlock()
+	dtmimp.PanicIf(factory == nil, fmt.Errorf("store factory of driver %s is nil", name))
+	_, dup := storeFactorys[name]
+	dtmimp.PanicIf(dup, fmt.Errorf("store driver %s registered twice", name))
+	storeFactorys[name] = factory
 }
 
-// GetStore returns storage.Store
+// Drivers returns the sorted names of the registered drivers
+func Drivers() []string {
+	factorysMu.RLock()
+	defer factorysMu.RUnlock()
+	names := []string{}
+	for name := range storeFactorys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func getFactory(driver string) StorageFactory {
+	factorysMu.RLock()
+	defer factorysMu.RUnlock()
+	return storeFactorys[driver]
+}
+
+// GetStore returns storage.Store.
+// the plugins in config.Store.Plugins are loaded if the driver is not registered
 func GetStore() storage.Store {
-	return storeFactorys[conf.Store.Driver].GetStorage()
+	fac := getFactory(conf.Store.Driver)
+	if fac == nil && conf.Store.Plugins != "" {
+		for _, path := range strings.Split(conf.Store.Plugins, ",") {
+			dtmimp.E2P(LoadPlugin(strings.TrimSpace(path)))
+		}
+		fac = getFactory(conf.Store.Driver)
+	}
+	dtmimp.PanicIf(fac == nil, fmt.Errorf("store driver %s not registered, registered drivers are: %s", conf.Store.Driver, strings.Join(Drivers(), ", ")))
+	return fac.GetStorage()
+}
+
+// GetStoreV2 returns the storage.StoreV2 of GetStore
+func GetStoreV2() storage.StoreV2 {
+	return storage.AsStoreV2(GetStore())
 }
 
 // WaitStoreUp wait for db to go up
@@ -0,0 +1,48 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package registry
+
+import (
+	"testing"
+
+	"github.com/dtm-labs/dtm/dtmcli/dtmimp"
+	"github.com/dtm-labs/dtm/dtmsvr/storage"
+	"github.com/dtm-labs/dtm/dtmsvr/storage/boltdb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegister(t *testing.T) {
+	old := conf.Store
+	defer func() { conf.Store = old }()
+
+	store := storage.AsStore(&boltdb.Store{})
+	created := 0
+	Register("test-register", NewSingletonFactory(func() storage.Store {
+		created++
+		return store
+	}))
+	assert.Contains(t, Drivers(), "test-register")
+	assert.Error(t, dtmimp.CatchP(func() {
+		Register("test-register", NewSingletonFactory(nil))
+	}))
+	assert.Error(t, dtmimp.CatchP(func() {
+		Register("test-nil", nil)
+	}))
+
+	conf.Store.Driver = "test-register"
+	assert.Equal(t, store, GetStore())
+	assert.Equal(t, store, GetStore())
+	assert.Equal(t, 1, created)
+
+	conf.Store.Driver = "test-unknown"
+	err := dtmimp.CatchP(func() { GetStore() })
+	assert.Contains(t, err.Error(), "test-unknown not registered")
+
+	conf.Store.Plugins = "./not-exists.so"
+	err = dtmimp.CatchP(func() { GetStore() })
+	assert.Contains(t, err.Error(), "not-exists.so")
+}
@@ -0,0 +1,107 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package sql
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dtm-labs/dtm/dtmsvr/storage"
+	"gorm.io/gorm"
+)
+
+// archivedTrans is a line of the archived file
+type archivedTrans struct {
+	Global   *storage.TransGlobalStore  `json:"global"`
+	Branches []storage.TransBranchStore `json:"branches"`
+}
+
+// ArchiveFinished moves at most limit trans finished before finishedBefore to conf.Store.Archive.Target,
+// and returns the num of archived trans. the ids are selected by the index of (status, update_time) without a locking read,
+// so that the live trans are not locked, and then the finished ones of them are moved in a db transaction.
+// the archived file may contain duplicated trans if the db transaction fails
+func (s *Store) ArchiveFinished(finishedBefore time.Time, limit int64) (int64, error) {
+	ids := []uint64{}
+	err := dbGet().Model(&storage.TransGlobalStore{}).
+		Where("status in ('succeed', 'failed') and update_time < ?", finishedBefore).
+		Order("update_time").Limit(int(limit)).Pluck("id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+	archived := int64(0)
+	err = dbGet().Transaction(func(tx *gorm.DB) error {
+		globals := []*storage.TransGlobalStore{}
+		err := tx.Where("id in ? and status in ('succeed', 'failed')", ids).Order("id").Find(&globals).Error
+		if err != nil || len(globals) == 0 {
+			return err
+		}
+		gids := []string{}
+		ids = ids[:0]
+		for _, g := range globals {
+			gids = append(gids, g.Gid)
+			ids = append(ids, g.ID)
+		}
+		branches := []storage.TransBranchStore{}
+		if err := tx.Where("gid in ?", gids).Order("id").Find(&branches).Error; err != nil {
+			return err
+		}
+		switch conf.Store.Archive.Target {
+		case "table":
+			err = tx.Table(conf.Store.Archive.TransGlobalTable).Create(&globals).Error
+			if err == nil && len(branches) > 0 {
+				err = tx.Table(conf.Store.Archive.TransBranchOpTable).Create(&branches).Error
+			}
+		case "file":
+			err = writeArchiveFile(globals, branches)
+		}
+		if err == nil {
+			err = tx.Where("gid in ?", gids).Delete(&storage.TransBranchStore{}).Error
+		}
+		if err == nil {
+			err = tx.Where("id in ? and status in ('succeed', 'failed')", ids).Delete(&storage.TransGlobalStore{}).Error
+		}
+		archived = int64(len(globals))
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return archived, nil
+}
+
+// writeArchiveFile writes the trans as gzipped json lines to a new file in conf.Store.Archive.Dir
+func writeArchiveFile(globals []*storage.TransGlobalStore, branches []storage.TransBranchStore) error {
+	dir := conf.Store.Archive.Dir
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	name := filepath.Join(dir, fmt.Sprintf("trans-%s-%d.jsonl.gz", time.Now().Format("20060102150405"), globals[0].ID))
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	byGid := map[string][]storage.TransBranchStore{}
+	for _, b := range branches {
+		byGid[b.Gid] = append(byGid[b.Gid], b)
+	}
+	zw := gzip.NewWriter(f)
+	enc := json.NewEncoder(zw)
+	for _, g := range globals {
+		if err := enc.Encode(&archivedTrans{Global: g, Branches: byGid[g.Gid]}); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return f.Sync()
+}
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS {cron_instance} (
+  `id` bigint(22) NOT NULL AUTO_INCREMENT,
+  `instance` varchar(128) NOT NULL COMMENT 'dtm instance taking part in sharded cron',
+  `expire_time` datetime NOT NULL COMMENT 'the instance is considered as left after this time',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `instance` (`instance`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+CREATE TABLE IF NOT EXISTS {trans_global_archive} LIKE {trans_global};
+CREATE TABLE IF NOT EXISTS {trans_branch_op_archive} LIKE {trans_branch_op};
@@ -0,0 +1,4 @@
+drop table IF EXISTS {trans_branch_op};
+drop table IF EXISTS {trans_global};
+drop sequence IF EXISTS {trans_branch_op}_seq;
+drop sequence IF EXISTS {trans_global}_seq;
@@ -0,0 +1,4 @@
+drop table IF EXISTS {trans_branch_op_archive};
+drop table IF EXISTS {trans_global_archive};
+drop table IF EXISTS {cron_instance};
+drop sequence IF EXISTS {cron_instance}_seq;
@@ -0,0 +1,10 @@
+CREATE SEQUENCE if not EXISTS {cron_instance}_seq;
+CREATE TABLE IF NOT EXISTS {cron_instance} (
+  id bigint NOT NULL DEFAULT NEXTVAL ('{cron_instance}_seq'),
+  instance varchar(128) NOT NULL,
+  expire_time timestamp(0) with time zone NOT NULL,
+  PRIMARY KEY (id),
+  CONSTRAINT instance UNIQUE (instance)
+);
+CREATE TABLE IF NOT EXISTS {trans_global_archive} (LIKE {trans_global} INCLUDING ALL);
+CREATE TABLE IF NOT EXISTS {trans_branch_op_archive} (LIKE {trans_branch_op} INCLUDING ALL);
@@ -0,0 +1 @@
+drop index IF EXISTS {schema}.status_update_time;
@@ -0,0 +1 @@
+create index if not EXISTS status_update_time on {trans_global} (status, update_time);
@@ -0,0 +1,2 @@
+drop table IF EXISTS {trans_branch_op};
+drop table IF EXISTS {trans_global};
@@ -0,0 +1,37 @@
+-- every schema is a database file attached by the sqlite driver of dtm, like dtm.db for schema dtm
+CREATE TABLE if not EXISTS {trans_global} (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  gid varchar(128) NOT NULL,
+  trans_type varchar(45) not null,
+  status varchar(12) NOT NULL,
+  query_prepared varchar(128) NOT NULL,
+  protocol varchar(45) not null,
+  create_time datetime DEFAULT NULL,
+  update_time datetime DEFAULT NULL,
+  finish_time datetime DEFAULT NULL,
+  rollback_time datetime DEFAULT NULL,
+  options varchar(1024) DEFAULT '',
+  custom_data varchar(256) DEFAULT '',
+  next_cron_interval int default null,
+  next_cron_time datetime default null,
+  owner varchar(128) not null default '',
+  ext_data text,
+  CONSTRAINT gid UNIQUE (gid)
+);
+create index if not EXISTS {schema}.owner on {trans_global_name}(owner);
+create index if not EXISTS {schema}.status_next_cron_time on {trans_global_name}(status, next_cron_time);
+CREATE TABLE IF NOT EXISTS {trans_branch_op} (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  gid varchar(128) NOT NULL,
+  url varchar(128) NOT NULL,
+  data TEXT,
+  bin_data BLOB,
+  branch_id VARCHAR(128) NOT NULL,
+  op varchar(45) NOT NULL,
+  status varchar(45) NOT NULL,
+  finish_time datetime DEFAULT NULL,
+  rollback_time datetime DEFAULT NULL,
+  create_time datetime DEFAULT NULL,
+  update_time datetime DEFAULT NULL,
+  CONSTRAINT gid_branch_uniq UNIQUE (gid, branch_id, op)
+);
@@ -0,0 +1,3 @@
+drop table IF EXISTS {trans_branch_op_archive};
+drop table IF EXISTS {trans_global_archive};
+drop table IF EXISTS {cron_instance};
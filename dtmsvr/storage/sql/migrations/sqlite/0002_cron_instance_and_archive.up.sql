@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS {cron_instance} (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  instance varchar(128) NOT NULL,
+  expire_time datetime NOT NULL,
+  CONSTRAINT instance UNIQUE (instance)
+);
+CREATE TABLE IF NOT EXISTS {trans_global_archive} AS SELECT * FROM {trans_global} WHERE 0;
+CREATE TABLE IF NOT EXISTS {trans_branch_op_archive} AS SELECT * FROM {trans_branch_op} WHERE 0;
@@ -0,0 +1 @@
+create index if not EXISTS {schema}.status_update_time on {trans_global_name}(status, update_time);
@@ -0,0 +1 @@
+ALTER TABLE {trans_global} DROP INDEX status_update_time;
@@ -0,0 +1 @@
+ALTER TABLE {trans_global} ADD INDEX status_update_time (status, update_time) COMMENT 'archive job will use this index to query trans';
@@ -0,0 +1,250 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package sql
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dtm-labs/dtm/dtmcli/logger"
+	"gorm.io/gorm"
+)
+
+// SchemaVersion is the schema version required by this version of dtm.
+// a change of the schema is a new migration in every dialect of migrations, with SchemaVersion increased,
+// and the scripts in sqls updated as the latest schema
+const SchemaVersion = 3
+
+// migrations/{dialect}/{version}_{name}.up.sql upgrades the schema to the version, and .down.sql downgrades it to the previous version.
+// the dialect is the driver of the store, or tdsql for the mysql of tdsql.
+// the tables in the migrations are the placeholders replaced by migrationTables, so that the tables configured in a schema other than dtm are migrated
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+// schema_version is in the schema of TransGlobalTable, and has a row for every applied migration
+var versionTableDDL = map[string]string{
+	"mysql":    "CREATE TABLE IF NOT EXISTS %s (`version` bigint(22) NOT NULL COMMENT 'version of the applied schema migration', `name` varchar(128) NOT NULL, `create_time` datetime DEFAULT NULL, PRIMARY KEY (`version`)) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4",
+	"postgres": "CREATE TABLE IF NOT EXISTS %s (version bigint NOT NULL, name varchar(128) NOT NULL, create_time timestamp(0) with time zone DEFAULT NULL, PRIMARY KEY (version))",
+	"sqlite":   "CREATE TABLE IF NOT EXISTS %s (version integer PRIMARY KEY, name varchar(128) NOT NULL, create_time datetime DEFAULT NULL)",
+}
+
+type migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+func loadMigrations(dialect string) ([]*migration, error) {
+	dir := path.Join("migrations", dialect)
+	entries, err := migrationFiles.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("migrations of dialect %s not found", dialect)
+	}
+	byVersion := map[int64]*migration{}
+	for _, e := range entries {
+		var version int64
+		parts := strings.SplitN(e.Name(), "_", 2)
+		if len(parts) == 2 {
+			version, err = strconv.ParseInt(parts[0], 10, 64)
+		}
+		if len(parts) != 2 || err != nil || !strings.HasSuffix(parts[1], ".sql") {
+			return nil, fmt.Errorf("bad migration file name: %s", e.Name())
+		}
+		cont, err := migrationFiles.ReadFile(path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		name := strings.TrimSuffix(parts[1], ".sql")
+		m := byVersion[version]
+		if m == nil {
+			m = &migration{Version: version}
+			byVersion[version] = m
+		}
+		if strings.HasSuffix(name, ".up") {
+			m.Name, m.Up = strings.TrimSuffix(name, ".up"), string(cont)
+		} else if strings.HasSuffix(name, ".down") {
+			m.Down = string(cont)
+		}
+	}
+	migrations := []*migration{}
+	for _, m := range byVersion {
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i, m := range migrations {
+		if m.Version != int64(i+1) || m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d of dialect %s should have both up and down files, and versions should start from 1 without gaps", m.Version, dialect)
+		}
+	}
+	if len(migrations) != SchemaVersion {
+		return nil, fmt.Errorf("dialect %s has %d migrations, but the schema version is %d", dialect, len(migrations), SchemaVersion)
+	}
+	return migrations, nil
+}
+
+// splitTable splits a table configured as schema.table
+func splitTable(table string) (schema string, name string, err error) {
+	parts := strings.SplitN(table, ".", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("table %s should be in the form of schema.table", table)
+	}
+	return parts[0], parts[1], nil
+}
+
+// migrationTables replaces the placeholders in the migrations by the tables in the config.
+// {schema} is the schema of TransGlobalTable, where schema_version is saved
+func migrationTables() (*strings.Replacer, error) {
+	schema, name, err := splitTable(conf.Store.TransGlobalTable)
+	if err != nil {
+		return nil, err
+	}
+	return strings.NewReplacer(
+		"{schema}", schema,
+		"{trans_global_name}", name,
+		"{trans_global}", conf.Store.TransGlobalTable,
+		"{trans_branch_op}", conf.Store.TransBranchOpTable,
+		"{cron_instance}", conf.Store.CronInstanceTable,
+		"{trans_global_archive}", conf.Store.Archive.TransGlobalTable,
+		"{trans_branch_op_archive}", conf.Store.Archive.TransBranchOpTable,
+	), nil
+}
+
+func tableExists(table string) (bool, error) {
+	schema, name, err := splitTable(table)
+	if err != nil {
+		return false, err
+	}
+	sql := "select count(*) from information_schema.tables where table_schema=? and table_name=?"
+	args := []interface{}{schema, name}
+	if conf.Store.Driver == "sqlite" {
+		sql = fmt.Sprintf("select count(*) from %s.sqlite_master where type='table' and name=?", schema)
+		args = args[1:]
+	}
+	var count int64
+	err = dbGet().Raw(sql, args...).Scan(&count).Error
+	return count > 0, err
+}
+
+// versionTable is the table schema_version in the schema of TransGlobalTable
+func versionTable() string {
+	schema, _, _ := splitTable(conf.Store.TransGlobalTable)
+	return schema + ".schema_version"
+}
+
+// GetSchemaVersion returns the schema version of the db.
+// the tables created before the versioned schema are considered as version 1, and 0 for an empty db
+func GetSchemaVersion() (version int64, err error) {
+	version, _, err = getSchemaVersion()
+	return
+}
+
+func getSchemaVersion() (version int64, legacy bool, err error) {
+	exists, err := tableExists(versionTable())
+	if err == nil && exists {
+		err = dbGet().Raw(fmt.Sprintf("select coalesce(max(version), 0) from %s", versionTable())).Scan(&version).Error
+	} else if err == nil {
+		legacy, err = tableExists(conf.Store.TransGlobalTable)
+		if legacy {
+			version = 1
+		}
+	}
+	return
+}
+
+// MigrateSchema upgrades or downgrades the schema of the db to the target version.
+// the migrations of version in (current, target] are applied for upgrading, and the ones in (target, current] are reverted for downgrading.
+// every migration is applied in a transaction, but mysql commits the ddl statements implicitly
+func MigrateSchema(dialect string, target int64) error {
+	migrations, err := loadMigrations(dialect)
+	if err != nil {
+		return err
+	}
+	if target < 0 || target > int64(len(migrations)) {
+		return fmt.Errorf("target version %d out of range [0, %d]", target, len(migrations))
+	}
+	tables, err := migrationTables()
+	if err != nil {
+		return err
+	}
+	current, legacy, err := getSchemaVersion()
+	if err != nil {
+		return err
+	}
+	db := dbGet()
+	if legacy {
+		logger.Infof("schema created before the versioned schema is recorded as version 1")
+		if err := db.Transaction(func(tx *gorm.DB) error { return recordVersion(tx, migrations[0], true) }); err != nil {
+			return err
+		}
+	}
+	for _, m := range migrations {
+		if m.Version > current && m.Version <= target && err == nil {
+			err = applyMigration(db.DB, tables, m, true)
+		}
+	}
+	for i := len(migrations) - 1; i >= 0; i-- {
+		if m := migrations[i]; m.Version > target && m.Version <= current && err == nil {
+			err = applyMigration(db.DB, tables, m, false)
+		}
+	}
+	return err
+}
+
+func applyMigration(db *gorm.DB, tables *strings.Replacer, m *migration, up bool) error {
+	script, action := m.Up, "up"
+	if !up {
+		script, action = m.Down, "down"
+	}
+	logger.Infof("migrating schema %s: %d_%s", action, m.Version, m.Name)
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, s := range strings.Split(tables.Replace(script), ";") {
+			s = strings.TrimSpace(s)
+			if s == "" {
+				continue
+			}
+			if err := tx.Exec(s).Error; err != nil {
+				return fmt.Errorf("migration %s of %d_%s failed: %w. sql: %s", action, m.Version, m.Name, err, s)
+			}
+		}
+		return recordVersion(tx, m, up)
+	})
+}
+
+func recordVersion(tx *gorm.DB, m *migration, up bool) error {
+	if !up {
+		return tx.Exec(fmt.Sprintf("delete from %s where version=?", versionTable()), m.Version).Error
+	}
+	err := tx.Exec(fmt.Sprintf(versionTableDDL[conf.Store.Driver], versionTable())).Error
+	if err == nil {
+		err = tx.Exec(fmt.Sprintf("insert into %s(version, name, create_time) values(?, ?, ?)", versionTable()), m.Version, m.Name, time.Now()).Error
+	}
+	return err
+}
+
+// CheckSchemaVersion returns an error if the schema version of the db is not SchemaVersion
+func CheckSchemaVersion() error {
+	version, legacy, err := getSchemaVersion()
+	if err != nil {
+		return err
+	}
+	if legacy {
+		logger.Warnf("schema version is not recorded, and considered as version 1. run `dtm schema up` to record it")
+	}
+	if version < SchemaVersion {
+		return fmt.Errorf("schema version %d is older than version %d required by this dtm. run `dtm schema up` to upgrade it", version, SchemaVersion)
+	} else if version > SchemaVersion {
+		return fmt.Errorf("schema version %d is newer than version %d required by this dtm. upgrade dtm, or run `dtm schema down -version %d` with the newer dtm", version, SchemaVersion, SchemaVersion)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package sql
+
+import (
+	"testing"
+
+	"github.com/dtm-labs/dtm/dtmsvr/config"
+	"github.com/dtm-labs/dtm/dtmsvr/storage"
+	"github.com/dtm-labs/dtm/dtmsvr/storage/storetest"
+	"github.com/dtm-labs/dtm/dtmutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func assertSchemaVersion(t *testing.T, expected int64) {
+	version, err := GetSchemaVersion()
+	assert.Nil(t, err)
+	assert.Equal(t, expected, version)
+}
+
+func TestLoadMigrations(t *testing.T) {
+	for _, dialect := range []string{"mysql", "postgres", "sqlite", "tdsql"} {
+		migrations, err := loadMigrations(dialect)
+		assert.Nil(t, err)
+		assert.Equal(t, SchemaVersion, len(migrations))
+	}
+	_, err := loadMigrations("unknown")
+	assert.Error(t, err)
+}
+
+// newSqliteConf returns the config of a sqlite store, whose tables are in schema
+func newSqliteConf(dir string, schema string) config.Store {
+	s := config.Store{Driver: config.Sqlite, Host: dir, TransGlobalTable: schema + ".trans_global",
+		TransBranchOpTable: schema + ".trans_branch_op", CronInstanceTable: schema + ".cron_instance"}
+	s.Archive.TransGlobalTable = schema + ".trans_global_archive"
+	s.Archive.TransBranchOpTable = schema + ".trans_branch_op_archive"
+	return s
+}
+
+func TestMigrateSchema(t *testing.T) {
+	old := conf.Store
+	defer func() { conf.Store = old }()
+	conf.Store = newSqliteConf(t.TempDir(), "dtm")
+
+	assertSchemaVersion(t, 0)
+	assert.Error(t, CheckSchemaVersion())
+	assert.Error(t, MigrateSchema("sqlite", SchemaVersion+1))
+
+	assert.Nil(t, MigrateSchema("sqlite", SchemaVersion))
+	assertSchemaVersion(t, SchemaVersion)
+	assert.Nil(t, CheckSchemaVersion())
+	assert.Nil(t, dbGet().Exec("insert into dtm.trans_global(gid, trans_type, status, query_prepared, protocol) values('g1', 'saga', 'prepared', '', 'http')").Error)
+
+	// a newer schema is rejected
+	assert.Nil(t, dbGet().Exec("insert into dtm.schema_version(version, name) values(?, 'newer')", SchemaVersion+1).Error)
+	assert.Error(t, CheckSchemaVersion())
+	assert.Nil(t, dbGet().Exec("delete from dtm.schema_version where version > ?", SchemaVersion).Error)
+
+	// the schema created by the scripts before versioning is recorded as version 1, and upgraded by the later migrations
+	for _, table := range []string{"schema_version", "cron_instance", "trans_global_archive", "trans_branch_op_archive"} {
+		assert.Nil(t, dbGet().Exec("drop table dtm."+table).Error)
+	}
+	assertSchemaVersion(t, 1)
+	assert.Error(t, CheckSchemaVersion())
+	assert.Nil(t, MigrateSchema("sqlite", SchemaVersion))
+	assert.Nil(t, CheckSchemaVersion())
+	for _, table := range []string{"schema_version", "cron_instance", "trans_global_archive", "trans_branch_op_archive"} {
+		exists, err := tableExists("dtm." + table)
+		assert.Nil(t, err)
+		assert.True(t, exists)
+	}
+
+	assert.Nil(t, MigrateSchema("sqlite", 0))
+	assertSchemaVersion(t, 0)
+	exists, err := tableExists("dtm.trans_global")
+	assert.Nil(t, err)
+	assert.False(t, exists)
+
+	s := storage.AsStore(&Store{})
+	s.PopulateData(false)
+	assertSchemaVersion(t, SchemaVersion)
+	var count int64
+	assert.Nil(t, dbGet().Raw("select count(*) from dtm.trans_global").Scan(&count).Error)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestMigrateCustomSchema(t *testing.T) {
+	old, oldSchemas := conf.Store, dtmutil.SqliteSchemas
+	defer func() { conf.Store, dtmutil.SqliteSchemas = old, oldSchemas }()
+	dtmutil.SqliteSchemas = append([]string{"mydb"}, oldSchemas...)
+	conf.Store = newSqliteConf(t.TempDir(), "mydb")
+
+	assert.Error(t, CheckSchemaVersion())
+	assert.Nil(t, MigrateSchema("sqlite", SchemaVersion))
+	assert.Nil(t, CheckSchemaVersion())
+	for _, table := range []string{"schema_version", "trans_global", "trans_branch_op", "cron_instance", "trans_global_archive", "trans_branch_op_archive"} {
+		exists, err := tableExists("mydb." + table)
+		assert.Nil(t, err)
+		assert.True(t, exists)
+		exists, err = tableExists("dtm." + table)
+		assert.Nil(t, err)
+		assert.False(t, exists)
+	}
+
+	// the legacy tables in the custom schema are detected as version 1
+	assert.Nil(t, dbGet().Exec("drop table mydb.schema_version").Error)
+	assertSchemaVersion(t, 1)
+	assert.Nil(t, MigrateSchema("sqlite", 0))
+	assertSchemaVersion(t, 0)
+
+	conf.Store.TransGlobalTable = "trans_global"
+	assert.Error(t, CheckSchemaVersion())
+}
+
+func TestConformance(t *testing.T) {
+	old := conf.Store
+	defer func() { conf.Store = old }()
+	config.MustLoadConfig("")
+	conf.Store.Driver, conf.Store.Host = config.Sqlite, t.TempDir()
+	assert.Nil(t, MigrateSchema("sqlite", SchemaVersion))
+
+	storetest.Run(t, storage.AsStore(&Store{}))
+}
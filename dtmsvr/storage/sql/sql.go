@@ -7,11 +7,17 @@
 package sql
 
 import (
+	"context"
 	"fmt"
 	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/dtm-labs/dtm/dtmcli/dtmimp"
+	"github.com/dtm-labs/dtm/dtmcli/logger"
 	"github.com/dtm-labs/dtm/dtmsvr/config"
 	"github.com/dtm-labs/dtm/dtmsvr/storage"
 	"github.com/dtm-labs/dtm/dtmutil"
@@ -22,176 +28,292 @@ import (
 
 var conf = &config.Config
 
-// Store implements storage.Store, and storage with db
+// Store implements storage.StoreV2, and storage with db
 type Store struct {
 }
 
-// Ping execs ping cmd to db
-func (s *Store) Ping() error {
+// PingContext execs ping cmd to db
+func (s *Store) PingContext(ctx context.Context) error {
 	db, err := dtmimp.StandaloneDB(conf.Store.GetDBConf())
-	dtmimp.E2P(err)
-	_, err = db.Exec("select 1")
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, "select 1")
 	return err
 }
 
-// PopulateData populates data to db
-func (s *Store) PopulateData(skipDrop bool) {
-	file := fmt.Sprintf("%s/dtmsvr.storage.%s.sql", dtmutil.GetSQLDir(), conf.Store.Driver)
-	dtmutil.RunSQLScript(conf.Store.GetDBConf(), file, skipDrop)
+// PopulateDataContext populates data to db by the schema migrations. the tables are dropped and recreated if !skipDrop
+func (s *Store) PopulateDataContext(ctx context.Context, skipDrop bool) error {
+	if !skipDrop {
+		if err := MigrateSchema(conf.Store.Driver, 0); err != nil {
+			return err
+		}
+	}
+	return MigrateSchema(conf.Store.Driver, SchemaVersion)
 }
 
-// FindTransGlobalStore finds GlobalTrans data by gid
-func (s *Store) FindTransGlobalStore(gid string) *storage.TransGlobalStore {
+// FindTransGlobalStoreContext finds GlobalTrans data by gid
+func (s *Store) FindTransGlobalStoreContext(ctx context.Context, gid string) (*storage.TransGlobalStore, error) {
 	trans := &storage.TransGlobalStore{}
-	dbr := dbGet().Model(trans).Where("gid=?", gid).First(trans)
-	if dbr.Error == gorm.ErrRecordNotFound {
-		return nil
+	err := dbRead(ctx, func(db *gorm.DB) *gorm.DB {
+		return db.Model(trans).Where("gid=?", gid).First(trans)
+	}).Error
+	if err != nil {
+		return nil, wrapError(err)
 	}
-	dtmimp.E2P(dbr.Error)
-	return trans
+	return trans, nil
 }
 
-// ScanTransGlobalStores lists GlobalTrans data
-func (s *Store) ScanTransGlobalStores(position *string, limit int64) []storage.TransGlobalStore {
+// ScanTransGlobalStoresContext lists GlobalTrans data
+func (s *Store) ScanTransGlobalStoresContext(ctx context.Context, position *string, limit int64) ([]storage.TransGlobalStore, error) {
 	globals := []storage.TransGlobalStore{}
-	lid := math.MaxInt64
+	lid := int64(math.MaxInt64)
 	if *position != "" {
-		lid = dtmimp.MustAtoi(*position)
+		var err error
+		if lid, err = strconv.ParseInt(*position, 10, 64); err != nil {
+			return nil, err
+		}
+	}
+	dbr := dbRead(ctx, func(db *gorm.DB) *gorm.DB {
+		return db.Where("id < ?", lid).Order("id desc").Limit(int(limit)).Find(&globals)
+	})
+	if dbr.Error != nil {
+		return nil, dbr.Error
 	}
-	dbr := dbGet().Must().Where("id < ?", lid).Order("id desc").Limit(int(limit)).Find(&globals)
 	if dbr.RowsAffected < limit {
 		*position = ""
 	} else {
 		*position = fmt.Sprintf("%d", globals[len(globals)-1].ID)
 	}
-	return globals
+	return globals, nil
 }
 
-// FindBranches finds Branch data by gid
-func (s *Store) FindBranches(gid string) []storage.TransBranchStore {
+// FindBranchesContext finds Branch data by gid
+func (s *Store) FindBranchesContext(ctx context.Context, gid string) ([]storage.TransBranchStore, error) {
 	branches := []storage.TransBranchStore{}
-	dbGet().Must().Where("gid=?", gid).Order("id asc").Find(&branches)
-	return branches
+	err := dbRead(ctx, func(db *gorm.DB) *gorm.DB {
+		return db.Where("gid=?", gid).Order("id asc").Find(&branches)
+	}).Error
+	return branches, err
 }
 
-// UpdateBranches update branches info
-func (s *Store) UpdateBranches(branches []storage.TransBranchStore, updates []string) (int, error) {
-	db := dbGet().Clauses(clause.OnConflict{
-		OnConstraint: "trans_branch_op_pkey",
-		DoUpdates:    clause.AssignmentColumns(updates),
+// UpdateBranchesContext update branches info
+func (s *Store) UpdateBranchesContext(ctx context.Context, branches []storage.TransBranchStore, updates []string) (int, error) {
+	db := dbCtx(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns(updates),
 	}).Create(branches)
 	return int(db.RowsAffected), db.Error
 }
 
-// LockGlobalSaveBranches creates branches
-func (s *Store) LockGlobalSaveBranches(gid string, status string, branches []storage.TransBranchStore, branchStart int) {
-	err := dbGet().Transaction(func(tx *gorm.DB) error {
+// LockGlobalSaveBranchesContext creates branches
+func (s *Store) LockGlobalSaveBranchesContext(ctx context.Context, gid string, status string, branches []storage.TransBranchStore, branchStart int) error {
+	return dbCtx(ctx).Transaction(func(tx *gorm.DB) error {
 		g := &storage.TransGlobalStore{}
-		dbr := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Model(g).Where("gid=? and status=?", gid, status).First(g)
+		dbr := ownerWhere(ctx, tx.Clauses(clause.Locking{Strength: "UPDATE"}).Model(g).Where("gid=? and status=?", gid, status)).First(g)
 		if dbr.Error == nil {
 			dbr = tx.Save(branches)
 		}
 		return wrapError(dbr.Error)
 	})
-	dtmimp.E2P(err)
 }
 
-// MaySaveNewTrans creates a new trans
-func (s *Store) MaySaveNewTrans(global *storage.TransGlobalStore, branches []storage.TransBranchStore) error {
-	return dbGet().Transaction(func(db1 *gorm.DB) error {
-		db := &dtmutil.DB{DB: db1}
-		dbr := db.Must().Clauses(clause.OnConflict{
+// MaySaveNewTransContext creates a new trans
+func (s *Store) MaySaveNewTransContext(ctx context.Context, global *storage.TransGlobalStore, branches []storage.TransBranchStore) error {
+	return dbCtx(ctx).Transaction(func(db *gorm.DB) error {
+		dbr := db.Clauses(clause.OnConflict{
 			DoNothing: true,
 		}).Create(global)
+		if dbr.Error != nil {
+			return dbr.Error
+		}
 		if dbr.RowsAffected <= 0 { // not a new trans, return
 			return storage.ErrUniqueConflict
 		}
 		if len(branches) > 0 {
-			db.Must().Clauses(clause.OnConflict{
+			return db.Clauses(clause.OnConflict{
 				DoNothing: true,
-			}).Create(&branches)
+			}).Create(&branches).Error
 		}
 		return nil
 	})
 }
 
-// ChangeGlobalStatus changes global trans status
-func (s *Store) ChangeGlobalStatus(global *storage.TransGlobalStore, newStatus string, updates []string, finished bool) {
+// MaySaveNewTransBatchContext creates many trans with multi-row inserts in one db transaction
+func (s *Store) MaySaveNewTransBatchContext(ctx context.Context, globals []*storage.TransGlobalStore, branches [][]storage.TransBranchStore) []error {
+	errs := make([]error, len(globals))
+	gids := []string{}
+	for _, g := range globals {
+		gids = append(gids, g.Gid)
+	}
+	existed := []string{}
+	if err := dbCtx(ctx).Model(&storage.TransGlobalStore{}).Where("gid in ?", gids).Pluck("gid", &existed).Error; err != nil {
+		for i := range errs {
+			errs[i] = err
+		}
+		return errs
+	}
+	conflicts := map[string]bool{}
+	for _, gid := range existed {
+		conflicts[gid] = true
+	}
+	newGlobals := []*storage.TransGlobalStore{}
+	newBranches := []storage.TransBranchStore{}
+	newPos := []int{}
+	for i, g := range globals {
+		if conflicts[g.Gid] {
+			errs[i] = storage.ErrUniqueConflict
+			continue
+		}
+		conflicts[g.Gid] = true
+		newGlobals = append(newGlobals, g)
+		newBranches = append(newBranches, branches[i]...)
+		newPos = append(newPos, i)
+	}
+	if len(newGlobals) == 0 {
+		return errs
+	}
+	err := dbCtx(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(&newGlobals).Error
+		if err == nil && len(newBranches) > 0 {
+			err = tx.Create(&newBranches).Error
+		}
+		return err
+	})
+	if err != nil { // some gid may be created concurrently, fallback to save them one by one
+		logger.Infof("batch save failed: %v, fallback to save one by one", err)
+		for _, i := range newPos {
+			errs[i] = s.MaySaveNewTransContext(ctx, globals[i], branches[i])
+		}
+	}
+	return errs
+}
+
+// ownerWhere adds the condition of the lease owner in ctx to db
+func ownerWhere(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if owner := storage.OwnerOf(ctx); owner != "" {
+		return db.Where("owner=?", owner)
+	}
+	return db
+}
+
+// ChangeGlobalStatusContext changes global trans status
+func (s *Store) ChangeGlobalStatusContext(ctx context.Context, global *storage.TransGlobalStore, newStatus string, updates []string, finished bool) error {
 	old := global.Status
 	global.Status = newStatus
-	dbr := dbGet().Must().Model(global).Where("status=? and gid=?", old, global.Gid).Select(updates).Updates(global)
-	if dbr.RowsAffected == 0 {
-		dtmimp.E2P(storage.ErrNotFound)
+	dbr := ownerWhere(ctx, dbCtx(ctx).Model(global).Where("status=? and gid=?", old, global.Gid)).Select(updates).Updates(global)
+	if dbr.Error == nil && dbr.RowsAffected == 0 {
+		return storage.ErrNotFound
 	}
+	return dbr.Error
 }
 
-// TouchCronTime updates cronTime
-func (s *Store) TouchCronTime(global *storage.TransGlobalStore, nextCronInterval int64, nextCronTime *time.Time) {
+// TouchCronTimeContext updates cronTime
+func (s *Store) TouchCronTimeContext(ctx context.Context, global *storage.TransGlobalStore, nextCronInterval int64, nextCronTime *time.Time) error {
 	global.UpdateTime = dtmutil.GetNextTime(0)
 	global.NextCronTime = nextCronTime
 	global.NextCronInterval = nextCronInterval
-	dbGet().Must().Model(global).Where("status=? and gid=?", global.Status, global.Gid).
-		Select([]string{"next_cron_time", "update_time", "next_cron_interval"}).Updates(global)
+	return dbCtx(ctx).Model(global).Where("status=? and gid=?", global.Status, global.Gid).
+		Select([]string{"next_cron_time", "update_time", "next_cron_interval"}).Updates(global).Error
 }
 
-// LockOneGlobalTrans finds GlobalTrans
-func (s *Store) LockOneGlobalTrans(expireIn time.Duration) *storage.TransGlobalStore {
-	db := dbGet()
-	getTime := func(second int) string {
-		return map[string]string{
-			"mysql":    fmt.Sprintf("date_add(now(), interval %d second)", second),
-			"postgres": fmt.Sprintf("current_timestamp + interval '%d second'", second),
-		}[conf.Store.Driver]
+// LockOneGlobalTransContext finds GlobalTrans, and leases it to a new owner for CronLeaseInterval
+func (s *Store) LockOneGlobalTransContext(ctx context.Context, expireIn time.Duration) (*storage.TransGlobalStore, error) {
+	globals, err := s.LockGlobalTransBatchContext(ctx, expireIn, 1, storage.ShardFilter{})
+	if err != nil || len(globals) == 0 {
+		return nil, err
 	}
+	return globals[0], nil
+}
+
+// LockGlobalTransBatchContext finds at most limit GlobalTrans in shards, in the order of next_cron_time, and leases them to a new owner for CronLeaseInterval
+func (s *Store) LockGlobalTransBatchContext(ctx context.Context, expireIn time.Duration, limit int64, shards storage.ShardFilter) ([]*storage.TransGlobalStore, error) {
+	globals := []*storage.TransGlobalStore{}
+	if shards.Total > 0 && len(shards.Owned) == 0 { // "in ()" is not valid for mysql and postgres
+		return globals, nil
+	}
+	db := dbCtx(ctx)
 	expire := int(expireIn / time.Second)
-	whereTime := fmt.Sprintf("next_cron_time < %s", getTime(expire))
+	where := fmt.Sprintf("next_cron_time < %s", getTimeExpr(expire)) + "and status in ('prepared', 'aborting', 'submitted')"
+	if shards.Total > 0 {
+		where += fmt.Sprintf(" and %s %% %d in (%s)", getShardExpr(), shards.Total, joinInts(shards.Owned))
+	}
 	owner := shortuuid.New()
-	global := &storage.TransGlobalStore{}
-	dbr := db.Must().Model(global).
-		Where(whereTime + "and status in ('prepared', 'aborting', 'submitted')").
-		Limit(1).
+	query := db.Model(&storage.TransGlobalStore{}).Where(where)
+	if conf.Store.Driver != dtmimp.DBTypeMysql { // postgres and sqlite do not support update ... limit
+		query = query.Where("id in (?)", db.Model(&storage.TransGlobalStore{}).Select("id").Where(where).Order("next_cron_time").Limit(int(limit)))
+	} else {
+		query = query.Order("next_cron_time").Limit(int(limit))
+	}
+	dbr := query.
 		Select([]string{"owner", "next_cron_time"}).
 		Updates(&storage.TransGlobalStore{
 			Owner:        owner,
-			NextCronTime: dtmutil.GetNextTime(conf.RetryInterval),
+			NextCronTime: dtmutil.GetNextTime(conf.CronLeaseInterval),
 		})
-	if dbr.RowsAffected == 0 {
-		return nil
+	if dbr.Error != nil || dbr.RowsAffected == 0 {
+		return globals, dbr.Error
 	}
-	db.Must().Where("owner=?", owner).First(global)
-	return global
+	err := db.Where("owner=?", owner).Find(&globals).Error
+	return globals, err
 }
 
-// ResetCronTime rest nextCronTime
-// Prevent multiple backoff from causing NextCronTime to be too long
-func (s *Store) ResetCronTime(timeout time.Duration, limit int64) (succeedCount int64, hasRemaining bool, err error) {
-	db := dbGet()
-	getTime := func(second int) string {
-		return map[string]string{
-			"mysql":    fmt.Sprintf("date_add(now(), interval %d second)", second),
-			"postgres": fmt.Sprintf("current_timestamp + interval '%d second'", second),
-		}[conf.Store.Driver]
+// RenewLeaseContext extends the lease of owner for another CronLeaseInterval
+func (s *Store) RenewLeaseContext(ctx context.Context, gid string, owner string) error {
+	dbr := dbCtx(ctx).Model(&storage.TransGlobalStore{}).
+		Where("gid=? and owner=? and status in ('prepared', 'aborting', 'submitted')", gid, owner).
+		Select([]string{"next_cron_time"}).
+		Updates(&storage.TransGlobalStore{NextCronTime: dtmutil.GetNextTime(conf.CronLeaseInterval)})
+	if dbr.Error == nil && dbr.RowsAffected == 0 {
+		return storage.ErrNotFound
 	}
+	return dbr.Error
+}
+
+// ReleaseLeaseContext releases the lease of global.Owner, and saves the next cron time of global
+func (s *Store) ReleaseLeaseContext(ctx context.Context, global *storage.TransGlobalStore) error {
+	owner := global.Owner
+	global.UpdateTime = dtmutil.GetNextTime(0)
+	global.Owner = ""
+	return dbCtx(ctx).Model(&storage.TransGlobalStore{}).
+		Where("gid=? and owner=? and status=? and status in ('prepared', 'aborting', 'submitted')", global.Gid, owner, global.Status).
+		Updates(map[string]interface{}{
+			"owner":              "",
+			"next_cron_time":     global.NextCronTime,
+			"next_cron_interval": global.NextCronInterval,
+			"update_time":        global.UpdateTime,
+		}).Error
+}
+
+// ResetCronTimeContext rest nextCronTime
+// Prevent multiple backoff from causing NextCronTime to be too long
+func (s *Store) ResetCronTimeContext(ctx context.Context, timeout time.Duration, limit int64) (succeedCount int64, hasRemaining bool, err error) {
+	db := dbCtx(ctx)
 	timeoutSecond := int(timeout / time.Second)
-	whereTime := fmt.Sprintf("next_cron_time > %s", getTime(timeoutSecond))
+	whereTime := fmt.Sprintf("next_cron_time > %s", getTimeExpr(timeoutSecond))
 	global := &storage.TransGlobalStore{}
-	dbr := db.Must().Model(global).
-		Where(whereTime + "and status in ('prepared', 'aborting', 'submitted')").
-		Limit(int(limit)).
+	query := db.Model(global).Where(whereTime + "and status in ('prepared', 'aborting', 'submitted')")
+	if conf.Store.Driver != dtmimp.DBTypeMysql { // postgres and sqlite do not support update ... limit
+		query = query.Where("id in (?)", db.Model(global).Select("id").
+			Where(whereTime+"and status in ('prepared', 'aborting', 'submitted')").Limit(int(limit)))
+	} else {
+		query = query.Limit(int(limit))
+	}
+	dbr := query.
 		Select([]string{"next_cron_time"}).
 		Updates(&storage.TransGlobalStore{
 			NextCronTime: dtmutil.GetNextTime(0),
 		})
+	if dbr.Error != nil {
+		return 0, false, dbr.Error
+	}
 	succeedCount = dbr.RowsAffected
 	if succeedCount == limit {
 		var count int64
-		db.Must().Model(global).Where(whereTime + "and status in ('prepared', 'aborting', 'submitted')").Limit(1).Count(&count)
-		if count > 0 {
-			hasRemaining = true
-		}
+		err = db.Model(global).Where(whereTime + "and status in ('prepared', 'aborting', 'submitted')").Limit(1).Count(&count).Error
+		hasRemaining = count > 0
 	}
-
-	return succeedCount, hasRemaining, dbr.Error
+	return succeedCount, hasRemaining, err
 }
 
 // SetDBConn sets db conn pool
@@ -202,14 +324,111 @@ func SetDBConn(db *gorm.DB) {
 	sqldb.SetConnMaxLifetime(time.Duration(conf.Store.ConnMaxLifeTime) * time.Minute)
 }
 
+// sqliteTimeFormat is the first of sqlite3.SQLiteTimestampFormats, used by the sqlite driver to save time.
+// go-sqlite3 is not referred here, so dtm can be built without cgo
+const sqliteTimeFormat = "2006-01-02 15:04:05.999999999-07:00"
+
+// getTimeExpr returns the sql expression of now + second.
+// the time of sqlite is saved as text, so the time is formatted like the one saved by the sqlite driver
+func getTimeExpr(second int) string {
+	return map[string]string{
+		"mysql":    fmt.Sprintf("date_add(now(), interval %d second)", second),
+		"postgres": fmt.Sprintf("current_timestamp + interval '%d second'", second),
+		"sqlite":   fmt.Sprintf("'%s'", time.Now().Add(time.Duration(second)*time.Second).Format(sqliteTimeFormat)),
+	}[conf.Store.Driver]
+}
+
+// getShardExpr returns the sql expression of a non negative hash of gid
+func getShardExpr() string {
+	return map[string]string{
+		"mysql":    "crc32(gid)",
+		"postgres": "(hashtext(gid) & 2147483647)",
+		"sqlite":   "crc32(gid)", // registered by the sqlite driver of dtmutil
+	}[conf.Store.Driver]
+}
+
+func joinInts(vs []int64) string {
+	strs := []string{}
+	for _, v := range vs {
+		strs = append(strs, strconv.FormatInt(v, 10))
+	}
+	return strings.Join(strs, ",")
+}
+
+// cronInstance is a dtm instance taking part in sharded cron
+type cronInstance struct {
+	ID         uint64
+	Instance   string
+	ExpireTime *time.Time
+}
+
+func (*cronInstance) TableName() string {
+	return conf.Store.CronInstanceTable
+}
+
+// KeepAliveInstanceContext registers instance as alive until expireIn later
+func (s *Store) KeepAliveInstanceContext(ctx context.Context, instance string, expireIn time.Duration) error {
+	expireTime := time.Now().Add(expireIn)
+	db := dbCtx(ctx)
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "instance"}},
+		DoUpdates: clause.AssignmentColumns([]string{"expire_time"}),
+	}).Create(&cronInstance{Instance: instance, ExpireTime: &expireTime}).Error
+	if err == nil {
+		err = db.Where("expire_time < ?", time.Now()).Delete(&cronInstance{}).Error
+	}
+	return err
+}
+
+// ListInstancesContext lists alive instances
+func (s *Store) ListInstancesContext(ctx context.Context) ([]string, error) {
+	instances := []string{}
+	err := dbCtx(ctx).Model(&cronInstance{}).Where("expire_time > ?", time.Now()).Order("instance").Pluck("instance", &instances).Error
+	return instances, err
+}
+
 func dbGet() *dtmutil.DB {
 	return dtmutil.DbGet(conf.Store.GetDBConf(), SetDBConn)
 }
 
+// dbCtx returns the db, whose queries are canceled when ctx is done
+func dbCtx(ctx context.Context) *gorm.DB {
+	return dbGet().WithContext(ctx)
+}
+
+var replicaNext uint64
+
+// dbRead runs query on the db for the reads in ctx. the read replicas are used in turn if ctx allows stale reads,
+// and the primary is used if the replica fails
+func dbRead(ctx context.Context, query func(db *gorm.DB) *gorm.DB) *gorm.DB {
+	if conf.Store.ReadReplicas == "" || !storage.StaleReadsAllowed(ctx) {
+		return query(dbCtx(ctx))
+	}
+	replicas := strings.Split(conf.Store.ReadReplicas, ",")
+	replica := conf.Store.GetDBConf()
+	replica.Host = strings.TrimSpace(replicas[atomic.AddUint64(&replicaNext, 1)%uint64(len(replicas))])
+	if host, port, err := net.SplitHostPort(replica.Host); err == nil {
+		if p, err := strconv.ParseInt(port, 10, 64); err == nil {
+			replica.Host, replica.Port = host, p
+		}
+	}
+	var dbr *gorm.DB
+	err := dtmimp.CatchP(func() {
+		dbr = query(dtmutil.DbGet(replica, SetDBConn).WithContext(ctx))
+	})
+	if err == nil && (dbr.Error == nil || dbr.Error == gorm.ErrRecordNotFound) {
+		return dbr
+	}
+	if err == nil {
+		err = dbr.Error
+	}
+	logger.Warnf("read replica %s failed, fallback to the primary: %v", replica.Host, err)
+	return query(dbCtx(ctx))
+}
+
 func wrapError(err error) error {
 	if err == gorm.ErrRecordNotFound {
 		return storage.ErrNotFound
 	}
-	dtmimp.E2P(err)
 	return err
 }
@@ -0,0 +1,60 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package sql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dtm-labs/dtm/dtmsvr/config"
+	"github.com/dtm-labs/dtm/dtmsvr/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadReplicas(t *testing.T) {
+	old := conf.Store
+	defer func() { conf.Store = old }()
+	config.MustLoadConfig("")
+	replica := t.TempDir()
+	conf.Store.Driver, conf.Store.Host = config.Sqlite, replica
+	assert.Nil(t, MigrateSchema("sqlite", SchemaVersion))
+	conf.Store.Host = t.TempDir()
+	assert.Nil(t, MigrateSchema("sqlite", SchemaVersion))
+	conf.Store.ReadReplicas = replica
+
+	s := &Store{}
+	next := time.Now().Add(10 * time.Second)
+	global := &storage.TransGlobalStore{Gid: "replica1", Status: "prepared", NextCronTime: &next}
+	assert.Nil(t, s.MaySaveNewTransContext(context.Background(), global, []storage.TransBranchStore{{Gid: "replica1", BranchID: "01"}}))
+
+	// the replica is not synced, so the trans is found only in the primary
+	_, err := s.FindTransGlobalStoreContext(context.Background(), "replica1")
+	assert.Nil(t, err)
+	ctx := storage.WithStaleReads(context.Background())
+	_, err = s.FindTransGlobalStoreContext(ctx, "replica1")
+	assert.Equal(t, storage.ErrNotFound, err)
+	branches, err := s.FindBranchesContext(ctx, "replica1")
+	assert.Nil(t, err)
+	assert.Empty(t, branches)
+	position := ""
+	globals, err := s.ScanTransGlobalStoresContext(ctx, &position, 10)
+	assert.Nil(t, err)
+	assert.Empty(t, globals)
+
+	// the replica without tables fails, and the primary is read instead
+	conf.Store.ReadReplicas = t.TempDir()
+	_, err = s.FindTransGlobalStoreContext(ctx, "replica1")
+	assert.Nil(t, err)
+	branches, err = s.FindBranchesContext(ctx, "replica1")
+	assert.Nil(t, err)
+	assert.Len(t, branches, 1)
+
+	conf.Store.ReadReplicas = ""
+	_, err = s.FindTransGlobalStoreContext(ctx, "replica1")
+	assert.Nil(t, err)
+}
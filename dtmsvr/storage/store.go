@@ -7,6 +7,7 @@
 package storage
 
 import (
+	"context"
 	"errors"
 	"time"
 )
@@ -27,8 +28,45 @@ type Store interface {
 	UpdateBranches(branches []TransBranchStore, updates []string) (int, error)
 	LockGlobalSaveBranches(gid string, status string, branches []TransBranchStore, branchStart int)
 	MaySaveNewTrans(global *TransGlobalStore, branches []TransBranchStore) error
+	MaySaveNewTransBatch(globals []*TransGlobalStore, branches [][]TransBranchStore) []error
 	ChangeGlobalStatus(global *TransGlobalStore, newStatus string, updates []string, finished bool)
 	TouchCronTime(global *TransGlobalStore, nextCronInterval int64, nextCronTime *time.Time)
 	LockOneGlobalTrans(expireIn time.Duration) *TransGlobalStore
+	LockGlobalTransBatch(expireIn time.Duration, limit int64, shards ShardFilter) []*TransGlobalStore
+	RenewLease(gid string, owner string) error
+	ReleaseLease(global *TransGlobalStore)
 	ResetCronTime(timeout time.Duration, limit int64) (succeedCount int64, hasRemaining bool, err error)
+	KeepAliveInstance(instance string, expireIn time.Duration)
+	ListInstances() []string
+}
+
+// StoreV2 defines the context-aware storage interface. the methods return errors instead of panics,
+// and give up with the error of ctx when ctx is canceled or its deadline is exceeded.
+// FindTransGlobalStoreContext returns ErrNotFound if the trans is not found, and LockOneGlobalTransContext returns nil if no trans is locked.
+// ChangeGlobalStatusContext and LockGlobalSaveBranchesContext return ErrNotFound if the trans is not leased to the owner of WithOwner
+type StoreV2 interface {
+	PingContext(ctx context.Context) error
+	PopulateDataContext(ctx context.Context, skipDrop bool) error
+	FindTransGlobalStoreContext(ctx context.Context, gid string) (*TransGlobalStore, error)
+	ScanTransGlobalStoresContext(ctx context.Context, position *string, limit int64) ([]TransGlobalStore, error)
+	FindBranchesContext(ctx context.Context, gid string) ([]TransBranchStore, error)
+	UpdateBranchesContext(ctx context.Context, branches []TransBranchStore, updates []string) (int, error)
+	LockGlobalSaveBranchesContext(ctx context.Context, gid string, status string, branches []TransBranchStore, branchStart int) error
+	MaySaveNewTransContext(ctx context.Context, global *TransGlobalStore, branches []TransBranchStore) error
+	MaySaveNewTransBatchContext(ctx context.Context, globals []*TransGlobalStore, branches [][]TransBranchStore) []error
+	ChangeGlobalStatusContext(ctx context.Context, global *TransGlobalStore, newStatus string, updates []string, finished bool) error
+	TouchCronTimeContext(ctx context.Context, global *TransGlobalStore, nextCronInterval int64, nextCronTime *time.Time) error
+	LockOneGlobalTransContext(ctx context.Context, expireIn time.Duration) (*TransGlobalStore, error)
+	LockGlobalTransBatchContext(ctx context.Context, expireIn time.Duration, limit int64, shards ShardFilter) ([]*TransGlobalStore, error)
+	RenewLeaseContext(ctx context.Context, gid string, owner string) error
+	ReleaseLeaseContext(ctx context.Context, global *TransGlobalStore) error
+	ResetCronTimeContext(ctx context.Context, timeout time.Duration, limit int64) (succeedCount int64, hasRemaining bool, err error)
+	KeepAliveInstanceContext(ctx context.Context, instance string, expireIn time.Duration) error
+	ListInstancesContext(ctx context.Context) ([]string, error)
+}
+
+// ShardFilter filters trans by the shard of gid. the filter is disabled if Total is 0
+type ShardFilter struct {
+	Total int64   // total number of shards
+	Owned []int64 // shards owned by this instance
 }
@@ -0,0 +1,280 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/dtm-labs/dtm/dtmcli/dtmimp"
+)
+
+// AsStore returns the Store of s, whose methods run with context.Background() and panic on errors
+func AsStore(s StoreV2) Store {
+	if a, ok := s.(*storeV2Adapter); ok {
+		return a.s
+	}
+	return &storeAdapter{s: s}
+}
+
+// AsStoreV2 returns the StoreV2 of s. s is returned if it is a Store of AsStore.
+// a store implementing only Store is adapted by calling its methods after checking ctx, so ctx is not honoured during the call
+func AsStoreV2(s Store) StoreV2 {
+	if a, ok := s.(*storeAdapter); ok {
+		return a.s
+	}
+	return &storeV2Adapter{s: s}
+}
+
+type staleReadsKey struct{}
+
+// WithStaleReads returns a context, in which the reads may be served by a read replica, so they may be stale
+func WithStaleReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, staleReadsKey{}, true)
+}
+
+// StaleReadsAllowed checks whether the reads in ctx may be stale
+func StaleReadsAllowed(ctx context.Context) bool {
+	allowed, _ := ctx.Value(staleReadsKey{}).(bool)
+	return allowed
+}
+
+type ownerKey struct{}
+
+// WithOwner returns a context, in which the status of a trans and its branches are only saved while the trans is leased to owner
+func WithOwner(ctx context.Context, owner string) context.Context {
+	return context.WithValue(ctx, ownerKey{}, owner)
+}
+
+// OwnerOf returns the owner of WithOwner in ctx, or empty if the writes in ctx are not fenced by a lease
+func OwnerOf(ctx context.Context) string {
+	owner, _ := ctx.Value(ownerKey{}).(string)
+	return owner
+}
+
+type storeAdapter struct {
+	s StoreV2
+}
+
+func (a *storeAdapter) Ping() error {
+	return a.s.PingContext(context.Background())
+}
+
+func (a *storeAdapter) PopulateData(skipDrop bool) {
+	dtmimp.E2P(a.s.PopulateDataContext(context.Background(), skipDrop))
+}
+
+func (a *storeAdapter) FindTransGlobalStore(gid string) *TransGlobalStore {
+	global, err := a.s.FindTransGlobalStoreContext(context.Background(), gid)
+	if err == ErrNotFound {
+		return nil
+	}
+	dtmimp.E2P(err)
+	return global
+}
+
+func (a *storeAdapter) ScanTransGlobalStores(position *string, limit int64) []TransGlobalStore {
+	globals, err := a.s.ScanTransGlobalStoresContext(context.Background(), position, limit)
+	dtmimp.E2P(err)
+	return globals
+}
+
+func (a *storeAdapter) FindBranches(gid string) []TransBranchStore {
+	branches, err := a.s.FindBranchesContext(context.Background(), gid)
+	dtmimp.E2P(err)
+	return branches
+}
+
+func (a *storeAdapter) UpdateBranches(branches []TransBranchStore, updates []string) (int, error) {
+	return a.s.UpdateBranchesContext(context.Background(), branches, updates)
+}
+
+func (a *storeAdapter) LockGlobalSaveBranches(gid string, status string, branches []TransBranchStore, branchStart int) {
+	dtmimp.E2P(a.s.LockGlobalSaveBranchesContext(context.Background(), gid, status, branches, branchStart))
+}
+
+func (a *storeAdapter) MaySaveNewTrans(global *TransGlobalStore, branches []TransBranchStore) error {
+	return a.s.MaySaveNewTransContext(context.Background(), global, branches)
+}
+
+func (a *storeAdapter) MaySaveNewTransBatch(globals []*TransGlobalStore, branches [][]TransBranchStore) []error {
+	return a.s.MaySaveNewTransBatchContext(context.Background(), globals, branches)
+}
+
+func (a *storeAdapter) ChangeGlobalStatus(global *TransGlobalStore, newStatus string, updates []string, finished bool) {
+	dtmimp.E2P(a.s.ChangeGlobalStatusContext(context.Background(), global, newStatus, updates, finished))
+}
+
+func (a *storeAdapter) TouchCronTime(global *TransGlobalStore, nextCronInterval int64, nextCronTime *time.Time) {
+	dtmimp.E2P(a.s.TouchCronTimeContext(context.Background(), global, nextCronInterval, nextCronTime))
+}
+
+func (a *storeAdapter) LockOneGlobalTrans(expireIn time.Duration) *TransGlobalStore {
+	global, err := a.s.LockOneGlobalTransContext(context.Background(), expireIn)
+	dtmimp.E2P(err)
+	return global
+}
+
+func (a *storeAdapter) LockGlobalTransBatch(expireIn time.Duration, limit int64, shards ShardFilter) []*TransGlobalStore {
+	globals, err := a.s.LockGlobalTransBatchContext(context.Background(), expireIn, limit, shards)
+	dtmimp.E2P(err)
+	return globals
+}
+
+func (a *storeAdapter) RenewLease(gid string, owner string) error {
+	return a.s.RenewLeaseContext(context.Background(), gid, owner)
+}
+
+func (a *storeAdapter) ReleaseLease(global *TransGlobalStore) {
+	dtmimp.E2P(a.s.ReleaseLeaseContext(context.Background(), global))
+}
+
+func (a *storeAdapter) ResetCronTime(timeout time.Duration, limit int64) (int64, bool, error) {
+	return a.s.ResetCronTimeContext(context.Background(), timeout, limit)
+}
+
+func (a *storeAdapter) KeepAliveInstance(instance string, expireIn time.Duration) {
+	dtmimp.E2P(a.s.KeepAliveInstanceContext(context.Background(), instance, expireIn))
+}
+
+func (a *storeAdapter) ListInstances() []string {
+	instances, err := a.s.ListInstancesContext(context.Background())
+	dtmimp.E2P(err)
+	return instances
+}
+
+type storeV2Adapter struct {
+	s Store
+}
+
+// call calls fn if ctx is not done, and returns the panic of fn as error
+func call(ctx context.Context, fn func()) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return dtmimp.CatchP(fn)
+}
+
+func firstError(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *storeV2Adapter) PingContext(ctx context.Context) error {
+	var err error
+	rerr := call(ctx, func() { err = a.s.Ping() })
+	return firstError(rerr, err)
+}
+
+func (a *storeV2Adapter) PopulateDataContext(ctx context.Context, skipDrop bool) error {
+	return call(ctx, func() { a.s.PopulateData(skipDrop) })
+}
+
+func (a *storeV2Adapter) FindTransGlobalStoreContext(ctx context.Context, gid string) (global *TransGlobalStore, err error) {
+	err = call(ctx, func() { global = a.s.FindTransGlobalStore(gid) })
+	if err == nil && global == nil {
+		err = ErrNotFound
+	}
+	return
+}
+
+func (a *storeV2Adapter) ScanTransGlobalStoresContext(ctx context.Context, position *string, limit int64) (globals []TransGlobalStore, err error) {
+	err = call(ctx, func() { globals = a.s.ScanTransGlobalStores(position, limit) })
+	return
+}
+
+func (a *storeV2Adapter) FindBranchesContext(ctx context.Context, gid string) (branches []TransBranchStore, err error) {
+	err = call(ctx, func() { branches = a.s.FindBranches(gid) })
+	return
+}
+
+func (a *storeV2Adapter) UpdateBranchesContext(ctx context.Context, branches []TransBranchStore, updates []string) (updated int, err error) {
+	rerr := call(ctx, func() { updated, err = a.s.UpdateBranches(branches, updates) })
+	return updated, firstError(rerr, err)
+}
+
+// checkOwner panics with ErrNotFound if gid is not leased to the owner of ctx.
+// Store has no context to fence its writes, so the owner is checked before the write, not atomically
+func (a *storeV2Adapter) checkOwner(ctx context.Context, gid string) {
+	if owner := OwnerOf(ctx); owner != "" {
+		g := a.s.FindTransGlobalStore(gid)
+		dtmimp.PanicIf(g == nil || g.Owner != owner, ErrNotFound)
+	}
+}
+
+func (a *storeV2Adapter) LockGlobalSaveBranchesContext(ctx context.Context, gid string, status string, branches []TransBranchStore, branchStart int) error {
+	return call(ctx, func() {
+		a.checkOwner(ctx, gid)
+		a.s.LockGlobalSaveBranches(gid, status, branches, branchStart)
+	})
+}
+
+func (a *storeV2Adapter) MaySaveNewTransContext(ctx context.Context, global *TransGlobalStore, branches []TransBranchStore) error {
+	var err error
+	rerr := call(ctx, func() { err = a.s.MaySaveNewTrans(global, branches) })
+	return firstError(rerr, err)
+}
+
+func (a *storeV2Adapter) MaySaveNewTransBatchContext(ctx context.Context, globals []*TransGlobalStore, branches [][]TransBranchStore) []error {
+	var errs []error
+	if err := call(ctx, func() { errs = a.s.MaySaveNewTransBatch(globals, branches) }); err != nil {
+		errs = make([]error, len(globals))
+		for i := range errs {
+			errs[i] = err
+		}
+	}
+	return errs
+}
+
+func (a *storeV2Adapter) ChangeGlobalStatusContext(ctx context.Context, global *TransGlobalStore, newStatus string, updates []string, finished bool) error {
+	return call(ctx, func() {
+		a.checkOwner(ctx, global.Gid)
+		a.s.ChangeGlobalStatus(global, newStatus, updates, finished)
+	})
+}
+
+func (a *storeV2Adapter) TouchCronTimeContext(ctx context.Context, global *TransGlobalStore, nextCronInterval int64, nextCronTime *time.Time) error {
+	return call(ctx, func() { a.s.TouchCronTime(global, nextCronInterval, nextCronTime) })
+}
+
+func (a *storeV2Adapter) LockOneGlobalTransContext(ctx context.Context, expireIn time.Duration) (global *TransGlobalStore, err error) {
+	err = call(ctx, func() { global = a.s.LockOneGlobalTrans(expireIn) })
+	return
+}
+
+func (a *storeV2Adapter) LockGlobalTransBatchContext(ctx context.Context, expireIn time.Duration, limit int64, shards ShardFilter) (globals []*TransGlobalStore, err error) {
+	err = call(ctx, func() { globals = a.s.LockGlobalTransBatch(expireIn, limit, shards) })
+	return
+}
+
+func (a *storeV2Adapter) RenewLeaseContext(ctx context.Context, gid string, owner string) error {
+	var err error
+	rerr := call(ctx, func() { err = a.s.RenewLease(gid, owner) })
+	return firstError(rerr, err)
+}
+
+func (a *storeV2Adapter) ReleaseLeaseContext(ctx context.Context, global *TransGlobalStore) error {
+	return call(ctx, func() { a.s.ReleaseLease(global) })
+}
+
+func (a *storeV2Adapter) ResetCronTimeContext(ctx context.Context, timeout time.Duration, limit int64) (succeedCount int64, hasRemaining bool, err error) {
+	rerr := call(ctx, func() { succeedCount, hasRemaining, err = a.s.ResetCronTime(timeout, limit) })
+	return succeedCount, hasRemaining, firstError(rerr, err)
+}
+
+func (a *storeV2Adapter) KeepAliveInstanceContext(ctx context.Context, instance string, expireIn time.Duration) error {
+	return call(ctx, func() { a.s.KeepAliveInstance(instance, expireIn) })
+}
+
+func (a *storeV2Adapter) ListInstancesContext(ctx context.Context) (instances []string, err error) {
+	err = call(ctx, func() { instances = a.s.ListInstances() })
+	return
+}
@@ -0,0 +1,402 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+// Package storetest implements the conformance tests of storage.Store, for the stores in registry and the third-party ones.
+// the stores and the tests read config.Config, so it should be loaded before running the tests.
+// the tests lock the unfinished trans in the store, so the store should have no unfinished trans of others,
+// and the tests should not run in parallel. the gids are unique in every run, so a store can be tested again without cleanup
+package storetest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lithammer/shortuuid/v3"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dtm-labs/dtm/dtmcli/dtmimp"
+	"github.com/dtm-labs/dtm/dtmsvr/config"
+	"github.com/dtm-labs/dtm/dtmsvr/storage"
+	"github.com/dtm-labs/dtm/dtmutil"
+)
+
+var conf = &config.Config
+
+// Run runs all the conformance tests of the store s as subtests
+func Run(t *testing.T, s storage.Store) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, s storage.Store)
+	}{
+		{"Save", TestSave},
+		{"Unique", TestUnique},
+		{"ChangeStatus", TestChangeStatus},
+		{"LockTrans", TestLockTrans},
+		{"CronOrder", TestCronOrder},
+		{"ResetCronTime", TestResetCronTime},
+		{"UpdateBranches", TestUpdateBranches},
+		{"Lease", TestLease},
+		{"Fence", TestFence},
+		{"LockTransBatch", TestLockTransBatch},
+		{"LockTransShards", TestLockTransShards},
+		{"Instances", TestInstances},
+		{"Scan", TestScan},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) { test.test(t, s) })
+	}
+}
+
+// NewGid returns a unique gid with the prefix name
+func NewGid(name string) string {
+	return fmt.Sprintf("storetest-%s-%s", name, shortuuid.New())
+}
+
+// InitTrans saves a prepared trans of gid with a branch, whose next cron time is next
+func InitTrans(t *testing.T, s storage.Store, gid string, next time.Time) *storage.TransGlobalStore {
+	g := &storage.TransGlobalStore{Gid: gid, Status: "prepared", NextCronTime: &next}
+	bs := []storage.TransBranchStore{
+		{Gid: gid, BranchID: "01"},
+	}
+	assert.Nil(t, s.MaySaveNewTrans(g, bs))
+	return g
+}
+
+func initTrans(t *testing.T, s storage.Store, name string) *storage.TransGlobalStore {
+	return InitTrans(t, s, NewGid(name), time.Now().Add(10*time.Second))
+}
+
+func retryIn(times int64) time.Duration {
+	return time.Duration(times*conf.RetryInterval) * time.Second
+}
+
+// TestSave tests saving trans and branches
+func TestSave(t *testing.T, s storage.Store) {
+	g := initTrans(t, s, "save")
+	gid := g.Gid
+	bs := []storage.TransBranchStore{
+		{Gid: gid, BranchID: "01"},
+		{Gid: gid, BranchID: "02"},
+	}
+	g2 := s.FindTransGlobalStore(gid)
+	assert.NotNil(t, g2)
+	assert.Equal(t, gid, g2.Gid)
+	assert.Nil(t, s.FindTransGlobalStore(gid+"-not-exists"))
+
+	bs2 := s.FindBranches(gid)
+	assert.Equal(t, len(bs2), int(1))
+	assert.Equal(t, "01", bs2[0].BranchID)
+
+	s.LockGlobalSaveBranches(gid, g.Status, []storage.TransBranchStore{bs[1]}, -1)
+	bs3 := s.FindBranches(gid)
+	assert.Equal(t, 2, len(bs3))
+	assert.Equal(t, "02", bs3[1].BranchID)
+	assert.Equal(t, "01", bs3[0].BranchID)
+
+	err := dtmimp.CatchP(func() {
+		s.LockGlobalSaveBranches(g.Gid, "submitted", []storage.TransBranchStore{bs[1]}, 1)
+	})
+	assert.Equal(t, storage.ErrNotFound, err)
+
+	s.ChangeGlobalStatus(g, "succeed", []string{}, true)
+}
+
+// TestUnique tests a gid can only be saved once, in single or batch
+func TestUnique(t *testing.T, s storage.Store) {
+	g := initTrans(t, s, "unique")
+	next := time.Now().Add(10 * time.Second)
+	g2 := &storage.TransGlobalStore{Gid: g.Gid, Status: "prepared", NextCronTime: &next}
+	assert.Equal(t, storage.ErrUniqueConflict, s.MaySaveNewTrans(g2, []storage.TransBranchStore{}))
+
+	g3 := &storage.TransGlobalStore{Gid: NewGid("unique"), Status: "prepared", NextCronTime: &next}
+	errs := s.MaySaveNewTransBatch([]*storage.TransGlobalStore{g2, g3}, [][]storage.TransBranchStore{{}, {{Gid: g3.Gid, BranchID: "01"}}})
+	assert.Equal(t, []error{storage.ErrUniqueConflict, nil}, errs)
+	assert.Equal(t, 1, len(s.FindBranches(g.Gid)))
+	assert.Equal(t, 1, len(s.FindBranches(g3.Gid)))
+
+	s.ChangeGlobalStatus(g, "succeed", []string{}, true)
+	s.ChangeGlobalStatus(g3, "succeed", []string{}, true)
+}
+
+// TestChangeStatus tests the status is changed only if the old status matches
+func TestChangeStatus(t *testing.T, s storage.Store) {
+	g := initTrans(t, s, "change-status")
+	g.Status = "no"
+	err := dtmimp.CatchP(func() {
+		s.ChangeGlobalStatus(g, "submitted", []string{}, false)
+	})
+	assert.Equal(t, storage.ErrNotFound, err)
+	g.Status = "prepared"
+	s.ChangeGlobalStatus(g, "submitted", []string{}, false)
+	assert.Equal(t, "submitted", s.FindTransGlobalStore(g.Gid).Status)
+	s.ChangeGlobalStatus(g, "succeed", []string{}, true)
+	assert.Equal(t, "succeed", s.FindTransGlobalStore(g.Gid).Status)
+	assert.Nil(t, s.LockOneGlobalTrans(retryIn(2))) // finished trans are not locked
+}
+
+// TestLockTrans tests a trans is locked after its next cron time
+func TestLockTrans(t *testing.T, s storage.Store) {
+	g := initTrans(t, s, "lock-trans")
+	gid := g.Gid
+
+	g2 := s.LockOneGlobalTrans(retryIn(2))
+	assert.NotNil(t, g2)
+	assert.Equal(t, gid, g2.Gid)
+
+	s.TouchCronTime(g, 3*conf.RetryInterval, dtmutil.GetNextTime(3*conf.RetryInterval))
+	g2 = s.LockOneGlobalTrans(retryIn(2))
+	assert.Nil(t, g2)
+
+	s.TouchCronTime(g, 1*conf.RetryInterval, dtmutil.GetNextTime(1*conf.RetryInterval))
+	g2 = s.LockOneGlobalTrans(retryIn(2))
+	assert.NotNil(t, g2)
+	assert.Equal(t, gid, g2.Gid)
+
+	s.ChangeGlobalStatus(g, "succeed", []string{}, true)
+	g2 = s.LockOneGlobalTrans(retryIn(2))
+	assert.Nil(t, g2)
+}
+
+// TestCronOrder tests the trans with an earlier next cron time is locked first
+func TestCronOrder(t *testing.T, s storage.Store) {
+	now := time.Now()
+	g1 := InitTrans(t, s, NewGid("cron-order"), now.Add(-20*time.Second))
+	g2 := InitTrans(t, s, NewGid("cron-order"), now.Add(-30*time.Second))
+	g3 := InitTrans(t, s, NewGid("cron-order"), now.Add(-10*time.Second))
+	for _, expected := range []*storage.TransGlobalStore{g2, g1, g3} {
+		g := s.LockOneGlobalTrans(0)
+		if assert.NotNil(t, g) {
+			assert.Equal(t, expected.Gid, g.Gid)
+		}
+	}
+	assert.Nil(t, s.LockOneGlobalTrans(0))
+	for _, g := range []*storage.TransGlobalStore{g1, g2, g3} {
+		s.ChangeGlobalStatus(g, "succeed", []string{}, true)
+	}
+}
+
+// TestResetCronTime tests ResetCronTime of the store
+func TestResetCronTime(t *testing.T, s storage.Store) {
+	TestResetCronTimeBy(t, s, func(timeout int64, limit int64) (int64, bool, error) {
+		return s.ResetCronTime(time.Duration(timeout)*time.Second, limit)
+	})
+}
+
+// TestResetCronTimeBy tests the next cron time of the trans after timeout is reset by resetCronTime, in batches of limit.
+// resetCronTime may reset the cron time by the api of dtm
+func TestResetCronTimeBy(t *testing.T, s storage.Store, resetCronTime func(timeout int64, limit int64) (int64, bool, error)) {
+	var restTimeTimeout, lockExpireIn, limit, i int64
+	restTimeTimeout = 100 //The time that will be ResetCronTime
+	lockExpireIn = 2      //The time that will be LockOneGlobalTrans
+	limit = 10            // rest limit
+
+	// Will be reset
+	for i = 0; i < limit; i++ {
+		InitTrans(t, s, NewGid("reset-cron-time"), time.Now().Add(time.Duration(restTimeTimeout+10)*time.Second))
+	}
+
+	// Will not be reset
+	InitTrans(t, s, NewGid("reset-cron-time"), time.Now().Add(time.Duration(restTimeTimeout-10)*time.Second))
+
+	// Not Fount
+	g := s.LockOneGlobalTrans(time.Duration(lockExpireIn) * time.Second)
+	assert.Nil(t, g)
+
+	// Rest limit-1 count
+	succeedCount, hasRemaining, err := resetCronTime(restTimeTimeout, limit-1)
+	assert.Equal(t, hasRemaining, true)
+	assert.Equal(t, succeedCount, limit-1)
+	assert.Nil(t, err)
+	// Fount limit-1 count
+	for i = 0; i < limit-1; i++ {
+		g = s.LockOneGlobalTrans(time.Duration(lockExpireIn) * time.Second)
+		assert.NotNil(t, g)
+		s.ChangeGlobalStatus(g, "succeed", []string{}, true)
+	}
+
+	// Not Fount
+	g = s.LockOneGlobalTrans(time.Duration(lockExpireIn) * time.Second)
+	assert.Nil(t, g)
+
+	// Rest 1 count
+	succeedCount, hasRemaining, err = resetCronTime(restTimeTimeout, limit)
+	assert.Equal(t, hasRemaining, false)
+	assert.Equal(t, succeedCount, int64(1))
+	assert.Nil(t, err)
+	// Fount 1 count
+	g = s.LockOneGlobalTrans(time.Duration(lockExpireIn) * time.Second)
+	assert.NotNil(t, g)
+	s.ChangeGlobalStatus(g, "succeed", []string{}, true)
+
+	// Not Fount
+	g = s.LockOneGlobalTrans(time.Duration(lockExpireIn) * time.Second)
+	assert.Nil(t, g)
+
+	// reduce the restTimeTimeout, Rest 1 count
+	succeedCount, hasRemaining, err = resetCronTime(restTimeTimeout-12, limit)
+	assert.Equal(t, hasRemaining, false)
+	assert.Equal(t, succeedCount, int64(1))
+	assert.Nil(t, err)
+	// Fount 1 count
+	g = s.LockOneGlobalTrans(time.Duration(lockExpireIn) * time.Second)
+	assert.NotNil(t, g)
+	s.ChangeGlobalStatus(g, "succeed", []string{}, true)
+
+	// Not Fount
+	g = s.LockOneGlobalTrans(time.Duration(lockExpireIn) * time.Second)
+	assert.Nil(t, g)
+
+	// Not Fount
+	succeedCount, hasRemaining, err = resetCronTime(restTimeTimeout-12, limit)
+	assert.Equal(t, hasRemaining, false)
+	assert.Equal(t, succeedCount, int64(0))
+	assert.Nil(t, err)
+}
+
+// TestUpdateBranches tests only the given columns of the branches are updated
+func TestUpdateBranches(t *testing.T, s storage.Store) {
+	g := initTrans(t, s, "update-branches")
+	gid := g.Gid
+	s.LockGlobalSaveBranches(gid, g.Status, []storage.TransBranchStore{{Gid: gid, BranchID: "01", Op: "action"}}, -1)
+	bs := s.FindBranches(gid)
+	assert.Equal(t, 2, len(bs))
+
+	now := time.Now()
+	bs[1].Status = "succeed"
+	bs[1].FinishTime = &now
+	bs[1].UpdateTime = &now
+	bs[1].URL = "not-updated"
+	_, err := s.UpdateBranches([]storage.TransBranchStore{bs[1]}, []string{"status", "finish_time", "update_time"})
+	assert.Nil(t, err)
+	bs2 := s.FindBranches(gid)
+	assert.Equal(t, 2, len(bs2))
+	assert.Equal(t, "", bs2[0].Status)
+	assert.Equal(t, "succeed", bs2[1].Status)
+	assert.WithinDuration(t, now, *bs2[1].FinishTime, time.Second)
+	assert.Equal(t, "", bs2[1].URL)
+	assert.Equal(t, "action", bs2[1].Op)
+
+	s.ChangeGlobalStatus(g, "succeed", []string{}, true)
+}
+
+// TestLease tests a locked trans is not locked by others until the lease expires or is released
+func TestLease(t *testing.T, s storage.Store) {
+	g := initTrans(t, s, "lease")
+	gid := g.Gid
+
+	g2 := s.LockOneGlobalTrans(retryIn(2))
+	assert.NotNil(t, g2)
+	assert.Equal(t, gid, g2.Gid)
+	assert.NotEqual(t, "", g2.Owner)
+	assert.Nil(t, s.LockOneGlobalTrans(0)) // not stolen before expired
+
+	assert.Nil(t, s.RenewLease(gid, g2.Owner))
+	assert.Equal(t, storage.ErrNotFound, s.RenewLease(gid, "other-owner"))
+
+	owner := g2.Owner
+	g2.NextCronInterval = conf.RetryInterval
+	g2.NextCronTime = dtmutil.GetNextTime(0)
+	s.ReleaseLease(g2)
+	assert.Equal(t, "", g2.Owner)
+	assert.Equal(t, storage.ErrNotFound, s.RenewLease(gid, owner))
+
+	g3 := s.LockOneGlobalTrans(2 * time.Second)
+	assert.NotNil(t, g3)
+	assert.NotEqual(t, owner, g3.Owner)
+
+	s.ChangeGlobalStatus(g, "succeed", []string{}, true)
+	assert.Equal(t, storage.ErrNotFound, s.RenewLease(gid, g3.Owner))
+	s.ReleaseLease(g3)
+	assert.Nil(t, s.LockOneGlobalTrans(retryIn(2)))
+}
+
+// TestFence tests the writes of status fenced by the owner of the lease
+func TestFence(t *testing.T, s storage.Store) {
+	g := initTrans(t, s, "fence")
+	gid := g.Gid
+	locked := s.LockOneGlobalTrans(retryIn(2))
+	assert.NotNil(t, locked)
+	assert.Equal(t, gid, locked.Gid)
+
+	s2 := storage.AsStoreV2(s)
+	other := storage.WithOwner(context.Background(), "other-owner")
+	mine := storage.WithOwner(context.Background(), locked.Owner)
+	branches := []storage.TransBranchStore{{Gid: gid, BranchID: "02"}}
+	assert.ErrorIs(t, s2.LockGlobalSaveBranchesContext(other, gid, "prepared", branches, -1), storage.ErrNotFound)
+	assert.Nil(t, s2.LockGlobalSaveBranchesContext(mine, gid, "prepared", branches, -1))
+	assert.Equal(t, 2, len(s.FindBranches(gid)))
+
+	stale := *g
+	assert.ErrorIs(t, s2.ChangeGlobalStatusContext(other, &stale, "succeed", []string{"status"}, true), storage.ErrNotFound)
+	assert.Equal(t, "prepared", s.FindTransGlobalStore(gid).Status)
+	assert.Nil(t, s2.ChangeGlobalStatusContext(mine, g, "succeed", []string{"status"}, true))
+}
+
+// TestLockTransBatch tests locking trans in batch
+func TestLockTransBatch(t *testing.T, s storage.Store) {
+	g1 := initTrans(t, s, "lock-trans-batch")
+	g2 := initTrans(t, s, "lock-trans-batch")
+
+	globals := s.LockGlobalTransBatch(retryIn(2), 10, storage.ShardFilter{})
+	assert.Equal(t, 2, len(globals))
+	if len(globals) > 0 {
+		assert.NotEqual(t, "", globals[0].Owner)
+	}
+	assert.Equal(t, 0, len(s.LockGlobalTransBatch(0, 10, storage.ShardFilter{})))
+
+	s.ChangeGlobalStatus(g1, "succeed", []string{}, true)
+	s.ChangeGlobalStatus(g2, "succeed", []string{}, true)
+}
+
+// TestLockTransShards tests a trans is locked only by the owner of its shard
+func TestLockTransShards(t *testing.T, s storage.Store) {
+	g1 := initTrans(t, s, "lock-trans-shards")
+	g2 := initTrans(t, s, "lock-trans-shards")
+
+	expireIn := retryIn(2)
+	assert.Equal(t, 0, len(s.LockGlobalTransBatch(expireIn, 10, storage.ShardFilter{Total: 2, Owned: []int64{}})))
+	locked := len(s.LockGlobalTransBatch(expireIn, 10, storage.ShardFilter{Total: 2, Owned: []int64{0}}))
+	locked += len(s.LockGlobalTransBatch(expireIn, 10, storage.ShardFilter{Total: 2, Owned: []int64{1}}))
+	assert.Equal(t, 2, locked)
+
+	s.ChangeGlobalStatus(g1, "succeed", []string{}, true)
+	s.ChangeGlobalStatus(g2, "succeed", []string{}, true)
+}
+
+// TestInstances tests only the alive instances are listed
+func TestInstances(t *testing.T, s storage.Store) {
+	prefix := NewGid("instances")
+	s.KeepAliveInstance(prefix+"-b", 10*time.Second)
+	s.KeepAliveInstance(prefix+"-a", 10*time.Second)
+	s.KeepAliveInstance(prefix+"-expired", -time.Second)
+	instances := s.ListInstances()
+	assert.Contains(t, instances, prefix+"-a")
+	assert.Contains(t, instances, prefix+"-b")
+	assert.NotContains(t, instances, prefix+"-expired")
+}
+
+// TestScan tests all the trans are scanned page by page
+func TestScan(t *testing.T, s storage.Store) {
+	gids := map[string]bool{}
+	for i := 0; i < 5; i++ {
+		g := initTrans(t, s, "scan")
+		gids[g.Gid] = true
+		s.ChangeGlobalStatus(g, "succeed", []string{}, true)
+	}
+	position := ""
+	for pages := 0; ; pages++ {
+		for _, g := range s.ScanTransGlobalStores(&position, 2) {
+			delete(gids, g.Gid)
+		}
+		if position == "" {
+			break
+		}
+		assert.Less(t, pages, 100000, "scan does not end")
+	}
+	assert.Empty(t, gids)
+}
@@ -7,6 +7,7 @@
 package storage
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/dtm-labs/dtm/dtmcli"
@@ -17,7 +18,9 @@ import (
 
 // TransGlobalExt defines Header info
 type TransGlobalExt struct {
-	Headers map[string]string `json:"headers,omitempty" gorm:"-"`
+	Headers     map[string]string `json:"headers,omitempty" gorm:"-"`
+	Fingerprint string            `json:"fingerprint,omitempty" gorm:"-"` // digest of the request body, to reject a different body with the same gid
+	Encrypted   []byte            `json:"encrypted,omitempty" gorm:"-"`   // encrypted json of Headers and Fingerprint, saved instead of them if encryption is enabled
 }
 
 // TransGlobalStore defines GlobalStore storage info
@@ -73,3 +76,51 @@ func (b *TransBranchStore) TableName() string {
 func (b *TransBranchStore) String() string {
 	return dtmimp.MustMarshalString(*b)
 }
+
+// MergeBranchUpdates sets the columns of the updates to the branches with the same gid, branch_id and op,
+// and returns the indexes of the changed branches. it is used by the stores saving the branches of a trans as a list,
+// where a branch is identified by branch_id and op, instead of the id of db
+func MergeBranchUpdates(branches []TransBranchStore, updates []TransBranchStore, columns []string) ([]int, error) {
+	changed := []int{}
+	seen := map[int]bool{}
+	for _, u := range updates {
+		for i := range branches {
+			b := &branches[i]
+			if b.Gid != u.Gid || b.BranchID != u.BranchID || b.Op != u.Op {
+				continue
+			}
+			for _, column := range columns {
+				switch column {
+				case "status":
+					b.Status = u.Status
+				case "finish_time":
+					b.FinishTime = u.FinishTime
+				case "rollback_time":
+					b.RollbackTime = u.RollbackTime
+				case "update_time":
+					b.UpdateTime = u.UpdateTime
+				default:
+					return nil, fmt.Errorf("column %s of branch can not be updated", column)
+				}
+			}
+			if !seen[i] {
+				seen[i] = true
+				changed = append(changed, i)
+			}
+		}
+	}
+	return changed, nil
+}
+
+// BranchGids returns the distinct gids of the branches in order
+func BranchGids(branches []TransBranchStore) []string {
+	gids := []string{}
+	seen := map[string]bool{}
+	for _, b := range branches {
+		if !seen[b.Gid] {
+			seen[b.Gid] = true
+			gids = append(gids, b.Gid)
+		}
+	}
+	return gids
+}
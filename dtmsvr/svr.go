@@ -18,8 +18,11 @@ import (
 	"github.com/dtm-labs/dtm/dtmcli/logger"
 	"github.com/dtm-labs/dtm/dtmgrpc/dtmgimp"
 	"github.com/dtm-labs/dtm/dtmgrpc/dtmgpb"
+	"github.com/dtm-labs/dtm/dtmsvr/broker"
+	"github.com/dtm-labs/dtm/dtmsvr/encryption"
 	"github.com/dtm-labs/dtm/dtmutil"
 	"github.com/dtm-labs/dtmdriver"
+	"github.com/go-redis/redis/v8"
 	"google.golang.org/grpc"
 )
 
@@ -63,9 +66,16 @@ func StartSvr() {
 		logger.FatalIfError(err)
 	}()
 
+	registerBrokers()
+	_, err = encryption.Default()
+	logger.FatalIfError(err)
+
 	for i := 0; i < int(conf.UpdateBranchAsyncGoroutineNum); i++ {
 		go updateBranchAsync()
 	}
+	if conf.Store.IsDB() && conf.Store.Archive.Expire > 0 {
+		go archiveFinished()
+	}
 
 	time.Sleep(100 * time.Millisecond)
 	err = dtmdriver.Use(conf.MicroService.Driver)
@@ -75,6 +85,19 @@ func StartSvr() {
 	logger.FatalIfError(err)
 }
 
+func registerBrokers() {
+	bc := conf.MsgBroker
+	if bc.RedisHost != "" {
+		client := redis.NewClient(&redis.Options{
+			Addr:     fmt.Sprintf("%s:%d", bc.RedisHost, bc.RedisPort),
+			Username: bc.RedisUser,
+			Password: bc.RedisPassword,
+		})
+		broker.Register("redis-stream", broker.NewRedisStreamProducer(client, bc.RedisMaxLen, time.Duration(conf.Store.DataExpire)*time.Second))
+		logger.Infof("msg broker redis-stream registered: %s:%d", bc.RedisHost, bc.RedisPort)
+	}
+}
+
 // PopulateDB setup mysql data
 func PopulateDB(skipDrop bool) {
 	GetStore().PopulateData(skipDrop)
@@ -94,8 +117,10 @@ func updateBranchAsync() {
 			select {
 			case updateBranch := <-updateBranchAsyncChan:
 				updates = append(updates, TransBranch{
-					ModelBase:  dtmutil.ModelBase{ID: updateBranch.id},
+					ModelBase:  dtmutil.ModelBase{ID: updateBranch.id, UpdateTime: updateBranch.finishTime},
 					Gid:        updateBranch.gid,
+					BranchID:   updateBranch.branchID,
+					Op:         updateBranch.op,
 					Status:     updateBranch.status,
 					FinishTime: updateBranch.finishTime,
 				})
@@ -109,7 +134,7 @@ func updateBranchAsync() {
 				logger.Errorf("async update branch status error: %v", err)
 				time.Sleep(1 * time.Second)
 			} else {
-				logger.Infof("flushed %d branch status to db. affected: %d", len(updates), rowAffected)
+				logger.Infof("flushed %d branch status to store. affected: %d", len(updates), rowAffected)
 				updates = []TransBranch{}
 			}
 		}
@@ -18,8 +18,31 @@ type TransGlobal struct {
 	storage.TransGlobalStore
 	lastTouched      time.Time // record the start time of process
 	updateBranchSync bool
+	leased           bool              // locked by cron. cron time is saved when the lease is released
+	ctx              context.Context   // context of the request. nil for the trans processed in background
+	sharedPayloads   map[string][]byte // saved payloads referred by other branches, keyed by branch id and op
 }
 
+// context returns the context used to access the store
+func (t *TransGlobal) context() context.Context {
+	if t.ctx == nil {
+		return context.Background()
+	}
+	return t.ctx
+}
+
+// detachedContext keeps the values of the request context, but not its deadline and cancellation,
+// so that the writes after a branch succeeded are not canceled by a client timeout or disconnect
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (deadline time.Time, ok bool) { return }
+
+func (detachedContext) Done() <-chan struct{} { return nil }
+
+func (detachedContext) Err() error { return nil }
+
 func (t *TransGlobal) setupPayloads() {
 	// Payloads will be store in BinPayloads, Payloads is only used to Unmarshal
 	for _, p := range t.Payloads {
@@ -70,20 +93,37 @@ func TransFromContext(c *gin.Context) *TransGlobal {
 	e2p(err)
 	m := TransGlobal{}
 	dtmimp.MustUnmarshal(b, &m)
-	m.Status = dtmimp.Escape(m.Status)
-	m.Gid = dtmimp.Escape(m.Gid)
 	logger.Debugf("creating trans in prepare")
-	m.setupPayloads()
-	m.Ext.Headers = map[string]string{}
-	if len(m.PassthroughHeaders) > 0 {
-		for _, h := range m.PassthroughHeaders {
+	m.setupFromContext(c)
+	return &m
+}
+
+// TransesFromContext construct trans from a batch request, the body is an array of trans
+func TransesFromContext(c *gin.Context) []*TransGlobal {
+	b, err := c.GetRawData()
+	e2p(err)
+	ms := []*TransGlobal{}
+	dtmimp.MustUnmarshal(b, &ms)
+	for _, m := range ms {
+		m.setupFromContext(c)
+	}
+	return ms
+}
+
+func (t *TransGlobal) setupFromContext(c *gin.Context) {
+	t.ctx = c.Request.Context()
+	t.Status = dtmimp.Escape(t.Status)
+	t.Gid = dtmimp.Escape(t.Gid)
+	t.setupPayloads()
+	t.Ext.Headers = map[string]string{}
+	if len(t.PassthroughHeaders) > 0 {
+		for _, h := range t.PassthroughHeaders {
 			v := c.GetHeader(h)
 			if v != "" {
-				m.Ext.Headers[h] = v
+				t.Ext.Headers[h] = v
 			}
 		}
 	}
-	return &m
 }
 
 // TransFromDtmRequest TransFromContext
@@ -108,6 +148,7 @@ func TransFromDtmRequest(ctx context.Context, c *dtmgpb.DtmRequest) *TransGlobal
 			RequestTimeout:     o.RequestTimeout,
 		},
 	}}
+	r.ctx = ctx
 	if c.Steps != "" {
 		dtmimp.MustUnmarshalString(c.Steps, &r.Steps)
 	}
@@ -0,0 +1,91 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package dtmsvr
+
+import (
+	"context"
+	"errors"
+
+	"github.com/dtm-labs/dtm/dtmcli/dtmimp"
+	"github.com/dtm-labs/dtm/dtmsvr/encryption"
+)
+
+// getEncryptor returns the encryptor of the config, or nil if encryption is disabled
+var getEncryptor = encryption.Default
+
+// encryptData encrypts the payload to be saved, if encryption is enabled
+func encryptData(ctx context.Context, data []byte) ([]byte, error) {
+	e, err := getEncryptor()
+	if e == nil || err != nil {
+		return data, err
+	}
+	return e.Encrypt(ctx, data)
+}
+
+// decryptData decrypts the saved payload. the payload saved without encryption is returned as is
+func decryptData(ctx context.Context, data []byte) ([]byte, error) {
+	if !encryption.IsEncrypted(data) {
+		return data, nil
+	}
+	e, err := getEncryptor()
+	if err == nil && e == nil {
+		err = errors.New("encrypted data found, but encryption is not enabled")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return e.Decrypt(ctx, data)
+}
+
+// secretExt is the part of Ext encrypted at rest. the fingerprint is encrypted too,
+// because a digest of a low-entropy payload can be brute-forced offline
+type secretExt struct {
+	Headers     map[string]string `json:"headers,omitempty"`
+	Fingerprint string            `json:"fingerprint,omitempty"`
+}
+
+// marshalExt marshals Ext to ExtData, with the headers and fingerprint encrypted if encryption is enabled
+func (t *TransGlobal) marshalExt() {
+	ext := t.Ext
+	if len(ext.Headers) > 0 || ext.Fingerprint != "" {
+		data, err := encryptData(t.context(), dtmimp.MustMarshal(secretExt{Headers: ext.Headers, Fingerprint: ext.Fingerprint}))
+		e2p(err)
+		if encryption.IsEncrypted(data) {
+			ext.Headers, ext.Fingerprint, ext.Encrypted = nil, "", data
+		}
+	}
+	t.ExtData = dtmimp.MustMarshalString(ext)
+	if t.ExtData == "{}" {
+		t.ExtData = ""
+	}
+}
+
+// unmarshalExt unmarshals ExtData to Ext, with the headers and fingerprint decrypted
+func (t *TransGlobal) unmarshalExt() {
+	dtmimp.MustUnmarshalString(t.ExtData, &t.Ext)
+	if len(t.Ext.Encrypted) > 0 {
+		data, err := decryptData(t.context(), t.Ext.Encrypted)
+		e2p(err)
+		secret := secretExt{}
+		dtmimp.MustUnmarshal(data, &secret)
+		t.Ext.Headers, t.Ext.Fingerprint = secret.Headers, secret.Fingerprint
+	}
+}
+
+// dropPlainPayloads drops the plaintext payloads in the request if encryption is enabled,
+// because some stores save them along with the global
+func (t *TransGlobal) dropPlainPayloads() {
+	e, err := getEncryptor()
+	e2p(err)
+	if e == nil {
+		return
+	}
+	t.Payloads = nil
+	for _, step := range t.Steps {
+		delete(step, "data")
+	}
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package dtmsvr
+
+import (
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dtm-labs/dtm/dtmcli/dtmimp"
+	"github.com/dtm-labs/dtm/dtmsvr/encryption"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptPayloads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys")
+	key := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32)))
+	assert.Nil(t, ioutil.WriteFile(path, []byte("k1:"+key+"\n"), 0600))
+	p, err := encryption.NewLocalProvider(path)
+	assert.Nil(t, err)
+	e := encryption.NewEncryptor(p, time.Hour)
+	old := getEncryptor
+	defer func() { getEncryptor = old }()
+	getEncryptor = func() (*encryption.Encryptor, error) { return e, nil }
+
+	tg := &TransGlobal{}
+	tg.Gid = "encrypt1"
+	tg.TransType = "saga"
+	tg.Payloads = []string{`{"card":"4111"}`}
+	tg.Steps = []map[string]string{{"action": "http://busi/TransOut", "compensate": "http://busi/TransOutRevert"}}
+	tg.Ext.Headers = map[string]string{"Authorization": "token"}
+	tg.setupPayloads()
+	branches := tg.prepareNew()
+
+	assert.Nil(t, tg.Payloads)
+	assert.NotContains(t, tg.ExtData, "token")
+	assert.NotEqual(t, "", tg.Ext.Fingerprint)
+	assert.NotContains(t, tg.ExtData, tg.Ext.Fingerprint)
+	assert.Equal(t, "token", tg.Ext.Headers["Authorization"])
+	for _, b := range branches {
+		assert.True(t, encryption.IsEncrypted(b.BinData))
+		data, err := decryptData(context.Background(), b.BinData)
+		assert.Nil(t, err)
+		assert.Equal(t, `{"card":"4111"}`, string(data))
+	}
+
+	// the query apis return the encrypted payloads as is
+	queried := append([]TransBranch{}, branches...)
+	assert.Nil(t, readablePayloads(queried))
+	assert.Equal(t, branches, queried)
+
+	saved := &TransGlobal{}
+	saved.ExtData = tg.ExtData
+	saved.unmarshalExt()
+	assert.Equal(t, tg.Ext.Headers, saved.Ext.Headers)
+	assert.Equal(t, tg.Ext.Fingerprint, saved.Ext.Fingerprint)
+
+	// the encrypted fingerprint is still checked
+	saved = &TransGlobal{}
+	saved.ExtData = tg.ExtData
+	assert.Nil(t, tg.checkFingerprint(saved))
+	other := &TransGlobal{}
+	other.Gid = tg.Gid
+	other.Ext.Fingerprint = "other"
+	saved = &TransGlobal{}
+	saved.ExtData = tg.ExtData
+	assert.Error(t, other.checkFingerprint(saved))
+
+	getEncryptor = func() (*encryption.Encryptor, error) { return nil, nil }
+	_, err = decryptData(context.Background(), branches[0].BinData)
+	assert.Error(t, err)
+	plain := &TransGlobal{}
+	plain.Ext.Headers = map[string]string{"Authorization": "token"}
+	plain.marshalExt()
+	assert.Equal(t, dtmimp.MustMarshalString(plain.Ext), plain.ExtData)
+}
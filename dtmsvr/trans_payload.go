@@ -0,0 +1,125 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package dtmsvr
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/dtm-labs/dtm/dtmsvr/compression"
+	"github.com/dtm-labs/dtm/dtmsvr/encryption"
+)
+
+// payloadRefMagic is the prefix of the payload that refers to the payload of another op of the same branch
+var payloadRefMagic = []byte("\x00dtmref\x01")
+
+// sharePayload returns a reference to the payload of op, if dedup is enabled and the reference is shorter
+func sharePayload(data []byte, op string) []byte {
+	if conf.Compression.Dedup == 0 || len(data) <= len(payloadRefMagic)+len(op) {
+		return data
+	}
+	return append(append([]byte{}, payloadRefMagic...), op...)
+}
+
+// payloadRefOp returns the op referred by data, or "" if data is not a reference
+func payloadRefOp(data []byte) string {
+	if !bytes.HasPrefix(data, payloadRefMagic) {
+		return ""
+	}
+	return string(data[len(payloadRefMagic):])
+}
+
+// indexSharedPayloads keeps the payloads referred by other branches, so that they can be resolved in execBranch
+func (t *TransGlobal) indexSharedPayloads(branches []TransBranch) {
+	t.sharedPayloads = nil
+	for _, b := range branches {
+		if op := payloadRefOp(b.BinData); op != "" {
+			if t.sharedPayloads == nil {
+				t.sharedPayloads = map[string][]byte{}
+			}
+			t.sharedPayloads[b.BranchID+"/"+op] = nil
+		}
+	}
+	for _, b := range branches {
+		if _, ok := t.sharedPayloads[b.BranchID+"/"+b.Op]; ok {
+			t.sharedPayloads[b.BranchID+"/"+b.Op] = b.BinData
+		}
+	}
+}
+
+// branchPayload returns the saved payload of branch, with the reference resolved
+func (t *TransGlobal) branchPayload(branch *TransBranch) ([]byte, error) {
+	op := payloadRefOp(branch.BinData)
+	if op == "" {
+		return branch.BinData, nil
+	}
+	data := t.sharedPayloads[branch.BranchID+"/"+op]
+	if data == nil { // only non-empty payloads are shared
+		return nil, fmt.Errorf("payload of branch %s op %s referred by op %s not found", branch.BranchID, op, branch.Op)
+	}
+	return data, nil
+}
+
+// readablePayloads resolves the references and decompresses the saved payloads of branches for the query apis.
+// the encrypted payloads are returned as is, because the query apis are not authenticated
+func readablePayloads(branches []TransBranch) error {
+	t := &TransGlobal{}
+	t.indexSharedPayloads(branches)
+	for i := range branches {
+		data, err := t.branchPayload(&branches[i])
+		if err == nil && !encryption.IsEncrypted(data) {
+			data, err = compression.Decompress(data)
+		}
+		if err != nil {
+			return err
+		}
+		branches[i].BinData = data
+	}
+	return nil
+}
+
+// encodePayload compresses and then encrypts the payload to be saved
+func encodePayload(ctx context.Context, data []byte) ([]byte, error) {
+	if payloadRefOp(data) != "" {
+		return data, nil
+	}
+	if c := &conf.Compression; c.Algorithm != "" && int64(len(data)) >= c.Threshold {
+		var err error
+		if data, err = compression.Compress(c.Algorithm, data); err != nil {
+			return nil, err
+		}
+	}
+	return encryptData(ctx, data)
+}
+
+// decodePayload decrypts and then decompresses the saved payload
+func decodePayload(ctx context.Context, data []byte) ([]byte, error) {
+	data, err := decryptData(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	return compression.Decompress(data)
+}
+
+// encodeBranches compresses and encrypts the payloads of branches to be saved
+func encodeBranches(ctx context.Context, branches []TransBranch) error {
+	for i := range branches {
+		data, err := encodePayload(ctx, branches[i].BinData)
+		if err != nil {
+			return err
+		}
+		branches[i].BinData = data
+	}
+	return nil
+}
+
+// encodePayloads compresses and encrypts the payloads of the new branches
+func (t *TransGlobal) encodePayloads(branches []TransBranch) {
+	e2p(encodeBranches(t.context(), branches))
+	t.dropPlainPayloads()
+}
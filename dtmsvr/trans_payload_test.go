@@ -0,0 +1,75 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package dtmsvr
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dtm-labs/dtm/dtmcli/dtmimp"
+	"github.com/dtm-labs/dtm/dtmsvr/compression"
+	"github.com/dtm-labs/dtm/dtmsvr/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSagaPayloads(t *testing.T) {
+	old := conf.Compression
+	defer func() { conf.Compression = old }()
+	conf.Compression = config.Compression{Algorithm: "zstd", Threshold: 100, Dedup: 1}
+
+	large := strings.Repeat(`{"amount":30}`, 100)
+	tg := &TransGlobal{}
+	tg.Gid = "payload1"
+	tg.TransType = "saga"
+	tg.Payloads = []string{large, `{"amount":30}`}
+	tg.Steps = []map[string]string{
+		{"action": "http://busi/TransOut", "compensate": "http://busi/TransOutRevert"},
+		{"action": "http://busi/TransIn", "compensate": "http://busi/TransInRevert"},
+	}
+	tg.setupPayloads()
+	branches := tg.prepareNew()
+	assert.Equal(t, 4, len(branches))
+
+	// the large payload is compressed and shared by the compensate
+	assert.Equal(t, dtmimp.OpAction, payloadRefOp(branches[0].BinData))
+	assert.True(t, compression.IsCompressed(branches[1].BinData))
+	// the small payload is neither compressed nor shared
+	assert.Equal(t, `{"amount":30}`, string(branches[2].BinData))
+	assert.Equal(t, `{"amount":30}`, string(branches[3].BinData))
+
+	tg.indexSharedPayloads(branches)
+	for i, expected := range []string{large, large, `{"amount":30}`, `{"amount":30}`} {
+		data, err := tg.branchPayload(&branches[i])
+		assert.Nil(t, err)
+		data, err = decodePayload(context.Background(), data)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, string(data))
+	}
+
+	// the query apis read the payloads as they are submitted
+	queried := append([]TransBranch{}, branches...)
+	assert.Nil(t, readablePayloads(queried))
+	for i, expected := range []string{large, large, `{"amount":30}`, `{"amount":30}`} {
+		assert.Equal(t, expected, string(queried[i].BinData))
+	}
+	assert.Error(t, readablePayloads(append([]TransBranch{}, branches[:1]...)))
+
+	tg.indexSharedPayloads(branches[:1])
+	_, err := tg.branchPayload(&branches[0])
+	assert.Error(t, err)
+
+	// payloads saved before are still readable after compression and dedup are disabled
+	conf.Compression = config.Compression{}
+	tg.indexSharedPayloads(branches)
+	data, err := tg.branchPayload(&branches[0])
+	assert.Nil(t, err)
+	data, err = decodePayload(context.Background(), data)
+	assert.Nil(t, err)
+	assert.Equal(t, large, string(data))
+	assert.Equal(t, []byte(large), sharePayload([]byte(large), dtmimp.OpAction))
+}
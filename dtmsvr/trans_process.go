@@ -1,12 +1,14 @@
 package dtmsvr
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"time"
 
 	"github.com/dtm-labs/dtm/dtmcli"
 	"github.com/dtm-labs/dtm/dtmcli/dtmimp"
 	"github.com/dtm-labs/dtm/dtmcli/logger"
+	"github.com/dtm-labs/dtm/dtmsvr/storage"
 	"github.com/dtm-labs/dtm/dtmutil"
 )
 
@@ -22,10 +24,12 @@ func (t *TransGlobal) process(branches []TransBranch) error {
 		dtmimp.MustUnmarshalString(t.Options, &t.TransOptions)
 	}
 	if t.ExtData != "" {
-		dtmimp.MustUnmarshalString(t.ExtData, &t.Ext)
+		t.unmarshalExt()
 	}
+	t.indexSharedPayloads(branches)
 
 	if !t.WaitResult {
+		t.ctx = nil // the request may finish before the processing
 		go func() {
 			err := t.processInner(branches)
 			if err != nil {
@@ -34,6 +38,9 @@ func (t *TransGlobal) process(branches []TransBranch) error {
 		}()
 		return nil
 	}
+	if t.ctx != nil && !t.leased { // the request context is only used by the saving and lookups before processing
+		t.ctx = detachedContext{t.ctx}
+	}
 	submitting := t.Status == dtmcli.StatusSubmitted
 	err := t.processInner(branches)
 	if err != nil {
@@ -64,12 +71,23 @@ func (t *TransGlobal) processInner(branches []TransBranch) (rerr error) {
 }
 
 func (t *TransGlobal) saveNew() ([]TransBranch, error) {
+	branches := t.prepareNew()
+	err := GetStoreV2().MaySaveNewTransContext(t.context(), &t.TransGlobalStore, branches)
+	logger.Infof("MaySaveNewTrans result: %v, global: %v branches: %v",
+		err, t.TransGlobalStore.String(), dtmimp.MustMarshalString(branches))
+	if err == nil {
+		scheduleWakeup(&t.TransGlobalStore)
+		notifyWatchers(&watchEvent{Gid: t.Gid, TransType: t.TransType, Status: t.Status})
+	}
+	return branches, err
+}
+
+// prepareNew fills the fields of a new trans and generates its branches
+func (t *TransGlobal) prepareNew() []TransBranch {
 	t.NextCronInterval = t.getNextCronInterval(cronReset)
 	t.NextCronTime = dtmutil.GetNextTime(t.NextCronInterval)
-	t.ExtData = dtmimp.MustMarshalString(t.Ext)
-	if t.ExtData == "{}" {
-		t.ExtData = ""
-	}
+	t.Ext.Fingerprint = t.fingerprint()
+	t.marshalExt()
 	t.Options = dtmimp.MustMarshalString(t.TransOptions)
 	if t.Options == "{}" {
 		t.Options = ""
@@ -82,8 +100,58 @@ func (t *TransGlobal) saveNew() ([]TransBranch, error) {
 		branches[i].CreateTime = &now
 		branches[i].UpdateTime = &now
 	}
-	err := GetStore().MaySaveNewTrans(&t.TransGlobalStore, branches)
-	logger.Infof("MaySaveNewTrans result: %v, global: %v branches: %v",
-		err, t.TransGlobalStore.String(), dtmimp.MustMarshalString(branches))
-	return branches, err
+	t.encodePayloads(branches)
+	return branches
+}
+
+// fingerprint digests the steps, payloads and options of the request
+func (t *TransGlobal) fingerprint() string {
+	options := t.TransOptions
+	options.WaitResult = false // only affects how the request is replied
+	// custom data is not digested, because msg builds it only on submit, not on prepare
+	body := dtmimp.MustMarshal([]interface{}{t.TransType, t.Steps, t.BinPayloads, t.QueryPrepared, options})
+	return fmt.Sprintf("%x", sha256.Sum256(body))
+}
+
+// checkFingerprint checks that the request is the same as the saved trans with the same gid.
+// trans saved without fingerprint are not checked
+func (t *TransGlobal) checkFingerprint(saved *TransGlobal) error {
+	if saved.ExtData != "" {
+		saved.unmarshalExt()
+	}
+	if saved.Ext.Fingerprint != "" && saved.Ext.Fingerprint != t.Ext.Fingerprint {
+		return fmt.Errorf("gid '%s' is used by another trans with a different request body. %w", t.Gid, dtmcli.ErrFailure)
+	}
+	return nil
+}
+
+// saveNewBatch saves many trans with one storage write. errs[i] is the result of ts[i]
+func saveNewBatch(ts []*TransGlobal) (branches [][]TransBranch, errs []error) {
+	branches = make([][]TransBranch, len(ts))
+	errs = make([]error, len(ts))
+	globals := []*storage.TransGlobalStore{}
+	saving := [][]TransBranch{}
+	pos := []int{}
+	for i, t := range ts {
+		errs[i] = dtmimp.CatchP(func() {
+			branches[i] = t.prepareNew()
+		})
+		if errs[i] == nil {
+			globals = append(globals, &t.TransGlobalStore)
+			saving = append(saving, branches[i])
+			pos = append(pos, i)
+		}
+	}
+	if len(globals) > 0 {
+		saveErrs := GetStoreV2().MaySaveNewTransBatchContext(ts[0].context(), globals, saving)
+		for k, i := range pos {
+			errs[i] = saveErrs[k]
+			if errs[i] == nil {
+				scheduleWakeup(&ts[i].TransGlobalStore)
+				notifyWatchers(&watchEvent{Gid: ts[i].Gid, TransType: ts[i].TransType, Status: ts[i].Status})
+			}
+		}
+	}
+	logger.Infof("MaySaveNewTransBatch %d trans, results: %v", len(ts), errs)
+	return
 }
@@ -12,6 +12,7 @@ import (
 	"github.com/dtm-labs/dtm/dtmcli/logger"
 	"github.com/dtm-labs/dtm/dtmgrpc"
 	"github.com/dtm-labs/dtm/dtmgrpc/dtmgimp"
+	"github.com/dtm-labs/dtm/dtmsvr/broker"
 	"github.com/dtm-labs/dtm/dtmutil"
 	"github.com/dtm-labs/dtmdriver"
 	"github.com/lithammer/shortuuid/v3"
@@ -31,9 +32,15 @@ func (t *TransGlobal) touchCronTime(ctype cronType, delay uint64) {
 	} else {
 		nextCronTime = dtmutil.GetNextTime(nextCronInterval)
 	}
+	if t.leased { // saved when the lease is released, or the lease may be stolen during processing
+		t.NextCronInterval = nextCronInterval
+		t.NextCronTime = nextCronTime
+		return
+	}
 
-	GetStore().TouchCronTime(&t.TransGlobalStore, nextCronInterval, nextCronTime)
+	e2p(GetStoreV2().TouchCronTimeContext(t.context(), &t.TransGlobalStore, nextCronInterval, nextCronTime))
 	logger.Infof("TouchCronTime for: %s", t.TransGlobalStore.String())
+	scheduleWakeup(&t.TransGlobalStore)
 }
 
 func (t *TransGlobal) changeStatus(status string) {
@@ -47,9 +54,11 @@ func (t *TransGlobal) changeStatus(status string) {
 		updates = append(updates, "rollback_time")
 	}
 	t.UpdateTime = &now
-	GetStore().ChangeGlobalStatus(&t.TransGlobalStore, status, updates, status == dtmcli.StatusSucceed || status == dtmcli.StatusFailed)
+	e2p(GetStoreV2().ChangeGlobalStatusContext(t.context(), &t.TransGlobalStore, status, updates, status == dtmcli.StatusSucceed || status == dtmcli.StatusFailed))
 	logger.Infof("ChangeGlobalStatus to %s ok for %s", status, t.TransGlobalStore.String())
 	t.Status = status
+	scheduleWakeup(&t.TransGlobalStore)
+	notifyWatchers(&watchEvent{Gid: t.Gid, TransType: t.TransType, Status: status})
 }
 
 func (t *TransGlobal) changeBranchStatus(b *TransBranch, status string, branchPos int) {
@@ -57,13 +66,14 @@ func (t *TransGlobal) changeBranchStatus(b *TransBranch, status string, branchPo
 	b.Status = status
 	b.FinishTime = &now
 	b.UpdateTime = &now
-	if conf.Store.Driver != dtmimp.DBTypeMysql && conf.Store.Driver != dtmimp.DBTypePostgres || conf.UpdateBranchSync > 0 || t.updateBranchSync {
-		GetStore().LockGlobalSaveBranches(t.Gid, t.Status, []TransBranch{*b}, branchPos)
+	if conf.UpdateBranchSync > 0 || t.updateBranchSync {
+		e2p(GetStoreV2().LockGlobalSaveBranchesContext(t.context(), t.Gid, t.Status, []TransBranch{*b}, branchPos))
 		logger.Infof("LockGlobalSaveBranches ok: gid: %s old status: %s branches: %s",
 			b.Gid, dtmcli.StatusPrepared, b.String())
 	} else { // for better performance, batch the updates of branch status
-		updateBranchAsyncChan <- branchStatus{id: b.ID, gid: t.Gid, status: status, finishTime: &now}
+		updateBranchAsyncChan <- branchStatus{id: b.ID, gid: t.Gid, branchID: b.BranchID, op: b.Op, status: status, finishTime: &now}
 	}
+	notifyWatchers(&watchEvent{Gid: t.Gid, TransType: t.TransType, BranchID: b.BranchID, Op: b.Op, Status: status})
 }
 
 func (t *TransGlobal) isTimeout() bool {
@@ -89,6 +99,30 @@ func (t *TransGlobal) getURLResult(uri string, branchID, op string, branchPayloa
 	if uri == "" { // empty url is success
 		return nil
 	}
+	branchPayload, err := decodePayload(t.context(), branchPayload)
+	if err != nil {
+		return err
+	}
+	if producer, target := broker.Lookup(uri); producer != nil {
+		if t.TransType != "msg" {
+			return fmt.Errorf("broker url %s is only supported by msg. %w", uri, dtmcli.ErrFailure)
+		}
+		headers := map[string]string{}
+		for k, v := range t.Ext.Headers {
+			headers[k] = v
+		}
+		for k, v := range t.BranchHeaders {
+			headers[k] = v
+		}
+		return producer.Publish(target, &broker.Message{
+			Gid:       t.Gid,
+			TransType: t.TransType,
+			BranchID:  branchID,
+			Op:        op,
+			Headers:   headers,
+			Payload:   branchPayload,
+		})
+	}
 	if strings.HasPrefix(uri, "http://") || strings.HasPrefix(uri, "https://") {
 		if t.RequestTimeout != 0 {
 			dtmimp.RestyClient.SetTimeout(time.Duration(t.RequestTimeout) * time.Second)
@@ -167,7 +201,11 @@ func (t *TransGlobal) getURLResult(uri string, branchID, op string, branchPayloa
 }
 
 func (t *TransGlobal) getBranchResult(branch *TransBranch) (string, error) {
-	err := t.getURLResult(branch.URL, branch.BranchID, branch.Op, branch.BinData)
+	payload, err := t.branchPayload(branch)
+	if err != nil {
+		return "", err
+	}
+	err = t.getURLResult(branch.URL, branch.BranchID, branch.Op, payload)
 	if err == nil {
 		return dtmcli.StatusSucceed, nil
 	} else if t.TransType == "saga" && branch.Op == dtmimp.OpAction && errors.Is(err, dtmcli.ErrFailure) {
@@ -179,6 +217,9 @@ func (t *TransGlobal) getBranchResult(branch *TransBranch) (string, error) {
 }
 
 func (t *TransGlobal) execBranch(branch *TransBranch, branchPos int) error {
+	if t.leased && t.context().Err() != nil {
+		return errLeaseLost
+	}
 	status, err := t.getBranchResult(branch)
 	if status != "" {
 		t.changeBranchStatus(branch, status, branchPos)
@@ -25,10 +25,14 @@ func (t *transSagaProcessor) GenBranches() []TransBranch {
 	for i, step := range t.Steps {
 		branch := fmt.Sprintf("%02d", i+1)
 		for _, op := range []string{dtmimp.OpCompensate, dtmimp.OpAction} {
+			binData := t.BinPayloads[i]
+			if op == dtmimp.OpCompensate {
+				binData = sharePayload(binData, dtmimp.OpAction)
+			}
 			branches = append(branches, TransBranch{
 				Gid:      t.Gid,
 				BranchID: branch,
-				BinData:  t.BinPayloads[i],
+				BinData:  binData,
 				URL:      step[op],
 				Op:       op,
 				Status:   dtmcli.StatusPrepared,
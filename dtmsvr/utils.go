@@ -7,6 +7,7 @@
 package dtmsvr
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -20,6 +21,8 @@ import (
 type branchStatus struct {
 	id         uint64
 	gid        string
+	branchID   string
+	op         string
 	status     string
 	finishTime *time.Time
 }
@@ -33,6 +36,11 @@ func GetStore() storage.Store {
 	return registry.GetStore()
 }
 
+// GetStoreV2 returns storage.StoreV2
+func GetStoreV2() storage.StoreV2 {
+	return registry.GetStoreV2()
+}
+
 // TransProcessedTestChan only for test usage. when transaction processed once, write gid to this chan
 var TransProcessedTestChan chan string
 
@@ -43,9 +51,20 @@ func GenGid() string {
 
 // GetTransGlobal construct trans from db
 func GetTransGlobal(gid string) *TransGlobal {
-	trans := GetStore().FindTransGlobalStore(gid)
+	return getTransGlobal(context.Background(), gid)
+}
+
+func getTransGlobal(ctx context.Context, gid string) *TransGlobal {
+	trans, err := GetStoreV2().FindTransGlobalStoreContext(ctx, gid)
 	//nolint:staticcheck
-	dtmimp.PanicIf(trans == nil, fmt.Errorf("no TransGlobal with gid: %s found", gid))
+	dtmimp.PanicIf(err == storage.ErrNotFound, fmt.Errorf("no TransGlobal with gid: %s found", gid))
+	e2p(err)
 	//nolint:staticcheck
-	return &TransGlobal{TransGlobalStore: *trans}
+	return &TransGlobal{TransGlobalStore: *trans, ctx: ctx}
+}
+
+func (t *TransGlobal) findBranches() []TransBranch {
+	branches, err := GetStoreV2().FindBranchesContext(t.context(), t.Gid)
+	e2p(err)
+	return branches
 }
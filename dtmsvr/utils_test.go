@@ -7,7 +7,11 @@
 package dtmsvr
 
 import (
+	"context"
 	"testing"
+	"time"
+
+	"github.com/dtm-labs/dtm/dtmcli"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -29,3 +33,44 @@ func TestSetNextCron(t *testing.T) {
 	tg.TimeoutToFail = 3
 	assert.Equal(t, int64(3), tg.getNextCronInterval(cronReset))
 }
+
+func TestFingerprint(t *testing.T) {
+	t1 := TransGlobal{}
+	t1.TransType = "saga"
+	t1.Steps = []map[string]string{{"action": "http://busi/TransOut"}}
+	t1.BinPayloads = [][]byte{[]byte("{}")}
+	t2 := t1
+	t2.WaitResult = true
+	assert.Equal(t, t1.fingerprint(), t2.fingerprint())
+	t2.BinPayloads = [][]byte{[]byte(`{"amount":30}`)}
+	assert.NotEqual(t, t1.fingerprint(), t2.fingerprint())
+
+	saved := TransGlobal{}
+	assert.Nil(t, t1.checkFingerprint(&saved)) // saved without fingerprint
+	saved.ExtData = `{"fingerprint":"` + t1.fingerprint() + `"}`
+	t1.Ext.Fingerprint = t1.fingerprint()
+	assert.Nil(t, t1.checkFingerprint(&saved))
+	t2.Ext.Fingerprint = t2.fingerprint()
+	assert.ErrorIs(t, t2.checkFingerprint(&saved), dtmcli.ErrFailure)
+}
+
+type ctxKey struct{}
+
+func TestDetachedContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), ctxKey{}, "v"), time.Minute)
+	tg := &TransGlobal{ctx: detachedContext{ctx}}
+	cancel()
+	assert.Error(t, ctx.Err())
+	assert.Nil(t, tg.context().Err())
+	assert.Nil(t, tg.context().Done())
+	_, ok := tg.context().Deadline()
+	assert.False(t, ok)
+	assert.Equal(t, "v", tg.context().Value(ctxKey{}))
+}
+
+func TestLeaseLostCancelsProcessing(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	tg := &TransGlobal{leased: true, ctx: ctx}
+	cancel()
+	assert.Equal(t, errLeaseLost, tg.execBranch(&TransBranch{}, 0))
+}
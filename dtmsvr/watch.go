@@ -0,0 +1,158 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package dtmsvr
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/dtm-labs/dtm/dtmcli"
+	"github.com/dtm-labs/dtm/dtmcli/logger"
+)
+
+// watchEvent is a status change of a global trans or one of its branches.
+// BranchID and Op are empty for the change of the global trans
+type watchEvent struct {
+	Gid       string `json:"gid"`
+	TransType string `json:"trans_type"`
+	BranchID  string `json:"branch_id,omitempty"`
+	Op        string `json:"op,omitempty"`
+	Status    string `json:"status"`
+}
+
+func (e *watchEvent) isTerminal() bool {
+	return e.BranchID == "" && (e.Status == dtmcli.StatusSucceed || e.Status == dtmcli.StatusFailed)
+}
+
+// watcher receives the events matching gid and transType. empty fields match all
+type watcher struct {
+	gid       string
+	transType string
+	events    chan *watchEvent
+}
+
+func (w *watcher) match(e *watchEvent) bool {
+	return (w.gid == "" || w.gid == e.Gid) && (w.transType == "" || w.transType == e.TransType)
+}
+
+// watchPollInterval the interval to poll the store for the status of a watched gid,
+// so that the changes made by the other dtm servers are also sent
+var watchPollInterval = 3 * time.Second
+
+// errWatcherDropped is returned by svcWatch when the watcher of all trans is too slow, so that the client knows some events are lost
+var errWatcherDropped = errors.New("watcher is too slow to consume the events, and is dropped")
+
+// watcherBufferSize a watcher is dropped if more than this number of events are not consumed
+var watcherBufferSize = 100
+
+var watchers = struct {
+	sync.Mutex
+	all map[*watcher]bool
+}{all: map[*watcher]bool{}}
+
+// addWatcher registers a watcher. the events chan of the watcher will be closed
+// when it is removed, or when it is too slow to consume the events
+func addWatcher(gid string, transType string) *watcher {
+	w := &watcher{gid: gid, transType: transType, events: make(chan *watchEvent, watcherBufferSize)}
+	watchers.Lock()
+	defer watchers.Unlock()
+	watchers.all[w] = true
+	return w
+}
+
+func removeWatcher(w *watcher) {
+	watchers.Lock()
+	defer watchers.Unlock()
+	if watchers.all[w] {
+		delete(watchers.all, w)
+		close(w.events)
+	}
+}
+
+// notifyWatchers sends the event to the watchers of this process only.
+// the events are not shared between dtm servers, so a watcher of a trans_type only receives
+// the changes processed by the server it is connected to, while a watcher of a gid also polls the store
+func notifyWatchers(e *watchEvent) {
+	watchers.Lock()
+	defer watchers.Unlock()
+	for w := range watchers.all {
+		if !w.match(e) {
+			continue
+		}
+		select {
+		case w.events <- e:
+		default:
+			logger.Errorf("watcher of gid: '%s' trans_type: '%s' is too slow, dropped", w.gid, w.transType)
+			delete(watchers.all, w)
+			close(w.events)
+		}
+	}
+}
+
+// svcWatch calls send for every event matching gid and transType, until send returns an error,
+// done is closed, or the watched gid reaches a terminal status.
+// if gid is not empty, the current status of the trans is sent first, and the store is polled
+// every watchPollInterval, so that a status changed by another dtm server is sent too.
+// the branch events of the trans processed by another dtm server are not sent.
+// a watcher too slow to consume the events is dropped. the watcher of a gid then keeps polling the store,
+// and the others return errWatcherDropped
+func svcWatch(gid string, transType string, done <-chan struct{}, send func(e *watchEvent) error) (rerr error) {
+	w := addWatcher(gid, transType)
+	defer removeWatcher(w)
+	lastStatus := ""
+	// sendGlobal sends the status of the global trans if it is not sent yet
+	sendGlobal := func(e *watchEvent) (bool, error) {
+		if e.Status == lastStatus {
+			return false, nil
+		}
+		lastStatus = e.Status
+		err := send(e)
+		return err != nil || e.isTerminal(), err
+	}
+	poll := func() (bool, error) {
+		t := GetStore().FindTransGlobalStore(gid)
+		if t == nil || transType != "" && t.TransType != transType {
+			return false, nil
+		}
+		return sendGlobal(&watchEvent{Gid: gid, TransType: t.TransType, Status: t.Status})
+	}
+	var tick <-chan time.Time
+	if gid != "" {
+		if stop, err := poll(); stop {
+			return err
+		}
+		ticker := time.NewTicker(watchPollInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	events := w.events
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok && gid == "" {
+				return errWatcherDropped
+			} else if !ok {
+				events = nil // the status is still sent by polling
+				continue
+			}
+			if gid == "" || e.BranchID != "" {
+				if err := send(e); err != nil {
+					return err
+				}
+			} else if stop, err := sendGlobal(e); stop {
+				return err
+			}
+		case <-tick:
+			if stop, err := poll(); stop {
+				return err
+			}
+		case <-done:
+			return nil
+		}
+	}
+}
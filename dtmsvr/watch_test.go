@@ -0,0 +1,112 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package dtmsvr
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dtm-labs/dtm/dtmcli"
+	"github.com/dtm-labs/dtm/dtmsvr/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchFilter(t *testing.T) {
+	done := make(chan struct{})
+	got := make(chan *watchEvent, 10)
+	go func() {
+		_ = svcWatch("", "msg", done, func(e *watchEvent) error {
+			got <- e
+			return nil
+		})
+	}()
+	waitWatchers(1)
+	notifyWatchers(&watchEvent{Gid: "g1", TransType: "saga", Status: dtmcli.StatusSubmitted})
+	notifyWatchers(&watchEvent{Gid: "g2", TransType: "msg", Status: dtmcli.StatusSubmitted})
+	assert.Equal(t, "g2", (<-got).Gid)
+	close(done)
+	waitWatchers(0)
+}
+
+func TestWatchSlowAndSendError(t *testing.T) {
+	w := addWatcher("", "")
+	for i := 0; i <= watcherBufferSize; i++ {
+		notifyWatchers(&watchEvent{Gid: "g1", TransType: "saga", Status: dtmcli.StatusSubmitted})
+	}
+	n := 0
+	for range w.events {
+		n++
+	}
+	assert.Equal(t, watcherBufferSize, n)
+	removeWatcher(w)
+
+	errSend := errors.New("send failed")
+	done := make(chan error)
+	go func() {
+		done <- svcWatch("", "", nil, func(e *watchEvent) error { return errSend })
+	}()
+	waitWatchers(1)
+	notifyWatchers(&watchEvent{Gid: "g1", TransType: "saga", Status: dtmcli.StatusSubmitted})
+	assert.Equal(t, errSend, <-done)
+}
+
+func TestWatchDropped(t *testing.T) {
+	blocked := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- svcWatch("", "", nil, func(e *watchEvent) error {
+			<-blocked
+			return nil
+		})
+	}()
+	waitWatchers(1)
+	for i := 0; i <= watcherBufferSize+1; i++ {
+		notifyWatchers(&watchEvent{Gid: "g1", TransType: "saga", Status: dtmcli.StatusSubmitted})
+	}
+	close(blocked)
+	assert.Equal(t, errWatcherDropped, <-done)
+}
+
+func TestWatchPollStore(t *testing.T) {
+	old, oldInterval := conf.Store, watchPollInterval
+	defer func() { conf.Store, watchPollInterval = old, oldInterval }()
+	conf.Store = newSqliteStore(filepath.Join(t.TempDir(), "watch"))
+	watchPollInterval = 10 * time.Millisecond
+
+	// the trans is saved and finished by another dtm server, so no event is notified in this process
+	now := time.Now()
+	g := &storage.TransGlobalStore{Gid: "watch-poll", Status: dtmcli.StatusSubmitted, TransType: "saga", Protocol: "http",
+		NextCronInterval: 10, NextCronTime: &now, CustomData: "{}"}
+	assert.Nil(t, GetStore().MaySaveNewTrans(g, []storage.TransBranchStore{}))
+	got := make(chan *watchEvent, 10)
+	done := make(chan error)
+	go func() {
+		done <- svcWatch(g.Gid, "", nil, func(e *watchEvent) error {
+			got <- e
+			return nil
+		})
+	}()
+	assert.Equal(t, dtmcli.StatusSubmitted, (<-got).Status)
+	GetStore().ChangeGlobalStatus(g, dtmcli.StatusSucceed, []string{"status"}, true)
+	assert.Equal(t, dtmcli.StatusSucceed, (<-got).Status)
+	assert.Nil(t, <-done)
+	assert.Len(t, got, 0)
+}
+
+func waitWatchers(n int) {
+	for {
+		watchers.Lock()
+		cur := len(watchers.all)
+		watchers.Unlock()
+		if cur == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	_ "github.com/lib/pq"              // register postgres driver
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
@@ -26,6 +27,8 @@ type ModelBase struct {
 func getGormDialetor(driver string, dsn string) gorm.Dialector {
 	if driver == dtmcli.DBTypePostgres {
 		return postgres.Open(dsn)
+	} else if driver == dtmcli.DBTypeSqlite {
+		return &sqlite.Dialector{DriverName: dtmcli.DBTypeSqlite, DSN: dsn}
 	}
 	dtmimp.PanicIf(driver != dtmcli.DBTypeMysql, fmt.Errorf("unknown driver: %s", driver))
 	return mysql.Open(dsn)
@@ -0,0 +1,21 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package dtmutil
+
+import (
+	"database/sql"
+
+	"github.com/dtm-labs/dtm/dtmcli"
+)
+
+// SqliteSchemas are the schemas attached to every sqlite connection, so that the tables like dtm.trans_global work as mysql.
+// the schema is saved in the file {dir}/{schema}.db, where dir is the Host of the db config
+var SqliteSchemas = []string{"dtm", "dtm_barrier"}
+
+func init() {
+	sql.Register(dtmcli.DBTypeSqlite, newSqliteDriver())
+}
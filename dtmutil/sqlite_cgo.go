@@ -0,0 +1,59 @@
+//go:build cgo
+// +build cgo
+
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package dtmutil
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// sqliteDriver attaches SqliteSchemas to every connection.
+// the transactions begin immediately, so the rows read in a transaction are locked as select for update,
+// and the writers wait for each other in busy_timeout
+type sqliteDriver struct {
+	sqlite3.SQLiteDriver
+}
+
+func (d *sqliteDriver) Open(dir string) (driver.Conn, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	conn, err := d.SQLiteDriver.Open("file::memory:?_busy_timeout=10000&_txlock=immediate")
+	if err != nil {
+		return nil, err
+	}
+	c := conn.(*sqlite3.SQLiteConn)
+	for _, schema := range SqliteSchemas {
+		_, err = c.Exec(fmt.Sprintf("attach database '%s' as %s", filepath.Join(dir, schema+".db"), schema), nil)
+		if err == nil {
+			_, err = c.Exec(fmt.Sprintf("pragma %s.journal_mode=wal", schema), nil)
+		}
+		if err != nil {
+			break
+		}
+	}
+	if err == nil { // crc32(gid) is used by the sharded cron, as mysql
+		err = c.RegisterFunc("crc32", func(s string) int64 { return int64(crc32.ChecksumIEEE([]byte(s))) }, true)
+	}
+	if err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func newSqliteDriver() driver.Driver {
+	return &sqliteDriver{}
+}
@@ -0,0 +1,26 @@
+//go:build !cgo
+// +build !cgo
+
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package dtmutil
+
+import (
+	"database/sql/driver"
+	"errors"
+)
+
+// sqliteDriver is a stub for the binaries built with CGO_ENABLED=0, such as the released ones
+type sqliteDriver struct{}
+
+func (d *sqliteDriver) Open(dir string) (driver.Conn, error) {
+	return nil, errors.New("sqlite requires cgo, please build dtm with CGO_ENABLED=1")
+}
+
+func newSqliteDriver() driver.Driver {
+	return &sqliteDriver{}
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/dtm-labs/dtm/dtmsvr"
 	"github.com/dtm-labs/dtm/dtmsvr/config"
 	"github.com/dtm-labs/dtm/dtmsvr/storage/registry"
+	"github.com/dtm-labs/dtm/dtmsvr/storage/sql"
 
 	// load the microserver driver
 	_ "github.com/dtm-labs/dtmdriver-gozero"
@@ -38,8 +39,8 @@ func version() {
 
 func usage() {
 	cmd := filepath.Base(os.Args[0])
-	s := "Usage: %s [options]\n\n"
-	fmt.Fprintf(os.Stderr, s, cmd)
+	s := "Usage: %s [options]\n       %s migrate [migrate options]\n       %s schema [schema options] up|down|status\n\n"
+	fmt.Fprintf(os.Stderr, s, cmd, cmd, cmd)
 	flag.PrintDefaults()
 }
 
@@ -51,7 +52,13 @@ var confFile = flag.String("c", "", "Path to the server configuration file.")
 
 func main() {
 	flag.Parse()
-	if flag.NArg() > 0 || *isHelp {
+	if flag.Arg(0) == "migrate" {
+		migrate(flag.Args()[1:])
+		return
+	} else if flag.Arg(0) == "schema" {
+		schema(flag.Args()[1:])
+		return
+	} else if flag.NArg() > 0 || *isHelp {
 		usage()
 		return
 	} else if *isVersion {
@@ -70,6 +77,11 @@ func main() {
 	}
 	_, _ = maxprocs.Set(maxprocs.Logger(logger.Infof))
 	registry.WaitStoreUp()
+	if conf.Store.IsDB() { // dtm still starts, because the features of the newer schema may be unused
+		if err := sql.CheckSchemaVersion(); err != nil {
+			logger.Warnf("check schema version failed: %v", err)
+		}
+	}
 	dtmsvr.StartSvr()              // start dtmsvr api
 	go dtmsvr.CronExpiredTrans(-1) // start dtmsvr cron job
 	select {}
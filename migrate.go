@@ -0,0 +1,47 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/dtm-labs/dtm/dtmcli/logger"
+	"github.com/dtm-labs/dtm/dtmsvr"
+	"github.com/dtm-labs/dtm/dtmsvr/config"
+)
+
+// migrate runs the subcommand: dtm migrate -from src.yml -to dst.yml
+func migrate(args []string) {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	from := fs.String("from", "", "Path to the configuration file of the source store.")
+	to := fs.String("to", "", "Path to the configuration file of the destination store.")
+	opts := dtmsvr.MigrateOptions{}
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "Only scan the trans in the source store.")
+	fs.BoolVar(&opts.Verify, "verify", false, "Compare the trans in the source store with the destination store.")
+	fs.StringVar(&opts.StateFile, "state", "dtm-migrate.state", "Path to the file saving the scan position.")
+	fs.BoolVar(&opts.Resume, "resume", false, "Resume from the scan position saved in the state file.")
+	fs.Int64Var(&opts.BatchSize, "batch", 100, "Num of trans scanned in a batch.")
+	_ = fs.Parse(args)
+	if *from == "" || *to == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+	config.MustLoadConfig(*from)
+	src := config.Config.Store
+	config.MustLoadConfig(*to)
+	dst := config.Config.Store
+	conf := &config.Config
+	logger.InitLog2(conf.LogLevel, conf.Log.Outputs, conf.Log.RotationEnable, conf.Log.RotationConfigJSON)
+	stats, err := dtmsvr.Migrate(src, dst, opts)
+	fmt.Printf("scanned: %d migrated: %d existed: %d mismatched: %d\n", stats.Scanned, stats.Migrated, stats.Existed, stats.Mismatched)
+	logger.FatalIfError(err)
+	if stats.Mismatched > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,60 @@
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/dtm-labs/dtm/dtmcli/logger"
+	"github.com/dtm-labs/dtm/dtmsvr/config"
+	"github.com/dtm-labs/dtm/dtmsvr/storage/registry"
+	"github.com/dtm-labs/dtm/dtmsvr/storage/sql"
+)
+
+// schema runs the subcommand: dtm schema -c conf.yml up|down|status
+func schema(args []string) {
+	fs := flag.NewFlagSet("schema", flag.ExitOnError)
+	confFile := fs.String("c", "", "Path to the server configuration file.")
+	dialect := fs.String("dialect", "", "Dialect of the migrations, default to the store driver. tdsql for the mysql of tdsql.")
+	version := fs.Int64("version", -1, "Target version, default to the latest version for up, and the previous version for down.")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s schema [schema options] up|down|status\n\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	config.MustLoadConfig(*confFile)
+	conf := &config.Config
+	logger.InitLog2(conf.LogLevel, conf.Log.Outputs, conf.Log.RotationEnable, conf.Log.RotationConfigJSON)
+	logger.FatalfIf(!conf.Store.IsDB(), "schema migrations are only for mysql, postgres and sqlite, but the driver is %s", conf.Store.Driver)
+	if *dialect == "" {
+		*dialect = conf.Store.Driver
+	}
+	registry.WaitStoreUp()
+	current, err := sql.GetSchemaVersion()
+	logger.FatalIfError(err)
+	switch fs.Arg(0) {
+	case "up":
+		if *version < 0 {
+			*version = sql.SchemaVersion
+		}
+		logger.FatalIfError(sql.MigrateSchema(*dialect, *version))
+	case "down":
+		if *version < 0 {
+			*version = current - 1
+		}
+		logger.FatalIfError(sql.MigrateSchema(*dialect, *version))
+	case "status":
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+	current, err = sql.GetSchemaVersion()
+	logger.FatalIfError(err)
+	fmt.Printf("schema version: %d, required by this dtm: %d\n", current, sql.SchemaVersion)
+}
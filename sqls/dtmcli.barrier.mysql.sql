@@ -15,4 +15,14 @@ create table if not exists dtm_barrier.barrier(
   key(create_time),
   key(update_time),
   UNIQUE key(gid, branch_id, op, barrier_id)
-) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
\ No newline at end of file
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+drop table if exists dtm_barrier.outbox;
+create table if not exists dtm_barrier.outbox(
+  id bigint(22) PRIMARY KEY AUTO_INCREMENT,
+  gid varchar(128) NOT NULL,
+  protocol varchar(45) NOT NULL default 'http' comment 'protocol used to submit: http | grpc | json-rpc',
+  dtm varchar(128) NOT NULL comment 'the dtm server to submit',
+  data TEXT comment 'the msg to submit',
+  create_time datetime DEFAULT now(),
+  key(create_time)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
@@ -9,8 +9,20 @@ create table if not exists dtm_barrier.barrier(
   op varchar(45) default '',
   barrier_id varchar(45) default '',
   reason varchar(45) default '',
-  create_time timestamp(0) with time zone DEFAULT NULL,
-  update_time timestamp(0) with time zone DEFAULT NULL,
+  create_time timestamp(0) with time zone DEFAULT now(),
+  update_time timestamp(0) with time zone DEFAULT now(),
   PRIMARY KEY(id),
   CONSTRAINT uniq_barrier unique(gid, branch_id, op, barrier_id)
-);
\ No newline at end of file
+);
+create index if not EXISTS create_time on dtm_barrier.barrier(create_time);
+drop table if exists dtm_barrier.outbox;
+CREATE SEQUENCE if not EXISTS dtm_barrier.outbox_seq;
+create table if not exists dtm_barrier.outbox(
+  id bigint NOT NULL DEFAULT NEXTVAL ('dtm_barrier.outbox_seq'),
+  gid varchar(128) NOT NULL,
+  protocol varchar(45) NOT NULL default 'http',
+  dtm varchar(128) NOT NULL,
+  data text,
+  create_time timestamp(0) with time zone DEFAULT now(),
+  PRIMARY KEY(id)
+);
@@ -0,0 +1,7 @@
+-- upgrade the barrier table created with create_time DEFAULT NULL, which is required by BarrierCleaner.
+-- the existing barriers without create_time are regarded as created now, and are cleaned after the retention
+alter table dtm_barrier.barrier alter column create_time set default now();
+alter table dtm_barrier.barrier alter column update_time set default now();
+update dtm_barrier.barrier set create_time = now() where create_time is null;
+update dtm_barrier.barrier set update_time = now() where update_time is null;
+create index if not EXISTS create_time on dtm_barrier.barrier(create_time);
@@ -0,0 +1,25 @@
+-- every schema is a database file attached by the sqlite driver of dtm, like dtm_barrier.db for schema dtm_barrier
+drop table if exists dtm_barrier.barrier;
+create table if not exists dtm_barrier.barrier(
+  id integer PRIMARY KEY AUTOINCREMENT,
+  trans_type varchar(45) default '',
+  gid varchar(128) default '',
+  branch_id varchar(128) default '',
+  op varchar(45) default '',
+  barrier_id varchar(45) default '',
+  reason varchar(45) default '',
+  create_time datetime DEFAULT CURRENT_TIMESTAMP,
+  update_time datetime DEFAULT CURRENT_TIMESTAMP,
+  CONSTRAINT uniq_barrier unique(gid, branch_id, op, barrier_id)
+);
+create index if not EXISTS dtm_barrier.create_time on barrier(create_time);
+drop table if exists dtm_barrier.outbox;
+create table if not exists dtm_barrier.outbox(
+  id integer PRIMARY KEY AUTOINCREMENT,
+  gid varchar(128) NOT NULL,
+  protocol varchar(45) NOT NULL default 'http',
+  dtm varchar(128) NOT NULL,
+  data text,
+  create_time datetime DEFAULT CURRENT_TIMESTAMP
+);
+create index if not EXISTS dtm_barrier.outbox_create_time on outbox(create_time);
@@ -22,7 +22,8 @@ CREATE TABLE if not EXISTS dtm.trans_global (
   PRIMARY KEY (`id`),
   UNIQUE KEY `gid` (`gid`),
   key `owner`(`owner`),
-  key `status_next_cron_time` (`status`, `next_cron_time`) comment 'cron job will use this index to query trans'
+  key `status_next_cron_time` (`status`, `next_cron_time`) comment 'cron job will use this index to query trans',
+  key `status_update_time` (`status`, `update_time`) comment 'archive job will use this index to query trans'
 ) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
 drop table IF EXISTS dtm.trans_branch_op;
 CREATE TABLE IF NOT EXISTS dtm.trans_branch_op (
@@ -41,3 +42,25 @@ CREATE TABLE IF NOT EXISTS dtm.trans_branch_op (
   PRIMARY KEY (`id`),
   UNIQUE KEY `gid_uniq` (`gid`, `branch_id`, `op`)
 ) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+drop table IF EXISTS dtm.cron_instance;
+CREATE TABLE IF NOT EXISTS dtm.cron_instance (
+  `id` bigint(22) NOT NULL AUTO_INCREMENT,
+  `instance` varchar(128) NOT NULL COMMENT 'dtm instance taking part in sharded cron',
+  `expire_time` datetime NOT NULL COMMENT 'the instance is considered as left after this time',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `instance` (`instance`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+drop table IF EXISTS dtm.trans_global_archive;
+CREATE TABLE IF NOT EXISTS dtm.trans_global_archive LIKE dtm.trans_global;
+drop table IF EXISTS dtm.trans_branch_op_archive;
+CREATE TABLE IF NOT EXISTS dtm.trans_branch_op_archive LIKE dtm.trans_branch_op;
+drop table IF EXISTS dtm.schema_version;
+CREATE TABLE IF NOT EXISTS dtm.schema_version (
+  `version` bigint(22) NOT NULL COMMENT 'version of the applied schema migration',
+  `name` varchar(128) NOT NULL,
+  `create_time` datetime DEFAULT NULL,
+  PRIMARY KEY (`version`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+insert ignore into dtm.schema_version(version, name, create_time) values(1, 'baseline', now());
+insert ignore into dtm.schema_version(version, name, create_time) values(2, 'cron_instance_and_archive', now());
+insert ignore into dtm.schema_version(version, name, create_time) values(3, 'archive_index', now());
@@ -26,6 +26,7 @@ CREATE TABLE if not EXISTS dtm.trans_global (
 );
 create index if not EXISTS owner on dtm.trans_global(owner);
 create index if not EXISTS status_next_cron_time on dtm.trans_global (status, next_cron_time);
+create index if not EXISTS status_update_time on dtm.trans_global (status, update_time);
 drop table IF EXISTS dtm.trans_branch_op;
 -- SQLINES LICENSE FOR EVALUATION USE ONLY
 CREATE SEQUENCE if not EXISTS dtm.trans_branch_op_seq;
@@ -44,4 +45,27 @@ CREATE TABLE IF NOT EXISTS dtm.trans_branch_op (
   update_time timestamp(0) with time zone DEFAULT NULL,
   PRIMARY KEY (id),
   CONSTRAINT gid_branch_uniq UNIQUE (gid, branch_id, op)
-);
\ No newline at end of file
+);
+drop table IF EXISTS dtm.cron_instance;
+CREATE SEQUENCE if not EXISTS dtm.cron_instance_seq;
+CREATE TABLE IF NOT EXISTS dtm.cron_instance (
+  id bigint NOT NULL DEFAULT NEXTVAL ('dtm.cron_instance_seq'),
+  instance varchar(128) NOT NULL,
+  expire_time timestamp(0) with time zone NOT NULL,
+  PRIMARY KEY (id),
+  CONSTRAINT instance UNIQUE (instance)
+);
+drop table IF EXISTS dtm.trans_global_archive;
+CREATE TABLE IF NOT EXISTS dtm.trans_global_archive (LIKE dtm.trans_global INCLUDING ALL);
+drop table IF EXISTS dtm.trans_branch_op_archive;
+CREATE TABLE IF NOT EXISTS dtm.trans_branch_op_archive (LIKE dtm.trans_branch_op INCLUDING ALL);
+drop table IF EXISTS dtm.schema_version;
+CREATE TABLE IF NOT EXISTS dtm.schema_version (
+  version bigint NOT NULL,
+  name varchar(128) NOT NULL,
+  create_time timestamp(0) with time zone DEFAULT NULL,
+  PRIMARY KEY (version)
+);
+insert into dtm.schema_version(version, name, create_time) values(1, 'baseline', now()) on conflict do nothing;
+insert into dtm.schema_version(version, name, create_time) values(2, 'cron_instance_and_archive', now()) on conflict do nothing;
+insert into dtm.schema_version(version, name, create_time) values(3, 'archive_index', now()) on conflict do nothing;
@@ -0,0 +1,60 @@
+-- every schema is a database file attached by the sqlite driver of dtm, like dtm.db for schema dtm
+drop table IF EXISTS dtm.trans_global;
+CREATE TABLE if not EXISTS dtm.trans_global (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  gid varchar(128) NOT NULL,
+  trans_type varchar(45) not null,
+  status varchar(12) NOT NULL,
+  query_prepared varchar(128) NOT NULL,
+  protocol varchar(45) not null,
+  create_time datetime DEFAULT NULL,
+  update_time datetime DEFAULT NULL,
+  finish_time datetime DEFAULT NULL,
+  rollback_time datetime DEFAULT NULL,
+  options varchar(1024) DEFAULT '',
+  custom_data varchar(256) DEFAULT '',
+  next_cron_interval int default null,
+  next_cron_time datetime default null,
+  owner varchar(128) not null default '',
+  ext_data text,
+  CONSTRAINT gid UNIQUE (gid)
+);
+create index if not EXISTS dtm.owner on trans_global(owner);
+create index if not EXISTS dtm.status_next_cron_time on trans_global(status, next_cron_time);
+create index if not EXISTS dtm.status_update_time on trans_global(status, update_time);
+drop table IF EXISTS dtm.trans_branch_op;
+CREATE TABLE IF NOT EXISTS dtm.trans_branch_op (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  gid varchar(128) NOT NULL,
+  url varchar(128) NOT NULL,
+  data TEXT,
+  bin_data BLOB,
+  branch_id VARCHAR(128) NOT NULL,
+  op varchar(45) NOT NULL,
+  status varchar(45) NOT NULL,
+  finish_time datetime DEFAULT NULL,
+  rollback_time datetime DEFAULT NULL,
+  create_time datetime DEFAULT NULL,
+  update_time datetime DEFAULT NULL,
+  CONSTRAINT gid_branch_uniq UNIQUE (gid, branch_id, op)
+);
+drop table IF EXISTS dtm.cron_instance;
+CREATE TABLE IF NOT EXISTS dtm.cron_instance (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  instance varchar(128) NOT NULL,
+  expire_time datetime NOT NULL,
+  CONSTRAINT instance UNIQUE (instance)
+);
+drop table IF EXISTS dtm.trans_global_archive;
+CREATE TABLE IF NOT EXISTS dtm.trans_global_archive AS SELECT * FROM dtm.trans_global WHERE 0;
+drop table IF EXISTS dtm.trans_branch_op_archive;
+CREATE TABLE IF NOT EXISTS dtm.trans_branch_op_archive AS SELECT * FROM dtm.trans_branch_op WHERE 0;
+drop table IF EXISTS dtm.schema_version;
+CREATE TABLE IF NOT EXISTS dtm.schema_version (
+  version integer PRIMARY KEY,
+  name varchar(128) NOT NULL,
+  create_time datetime DEFAULT NULL
+);
+insert or ignore into dtm.schema_version(version, name, create_time) values(1, 'baseline', datetime('now'));
+insert or ignore into dtm.schema_version(version, name, create_time) values(2, 'cron_instance_and_archive', datetime('now'));
+insert or ignore into dtm.schema_version(version, name, create_time) values(3, 'archive_index', datetime('now'));
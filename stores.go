@@ -0,0 +1,16 @@
+//go:build dtm_stores
+// +build dtm_stores
+
+/*
+ * Copyright (c) 2022 yedf. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+package main
+
+// the third-party stores, encryption providers and msg broker producers linked into dtm, which works without cgo, unlike Store.Plugins.
+// add a blank import of the package calling registry.Register, encryption.RegisterProvider or broker.Register in its init function,
+// then build dtm by `go build -tags dtm_stores`, for example:
+//
+//	import _ "github.com/your-org/dtm-store-tikv"
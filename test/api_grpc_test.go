@@ -0,0 +1,96 @@
+package test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/dtm-labs/dtm/dtmcli"
+	"github.com/dtm-labs/dtm/dtmcli/dtmimp"
+	"github.com/dtm-labs/dtm/dtmgrpc"
+	"github.com/dtm-labs/dtm/dtmutil"
+	"github.com/dtm-labs/dtm/test/busi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIGrpcQuery(t *testing.T) {
+	gid := dtmimp.GetFuncName()
+	err := genMsg(gid).Submit()
+	assert.Nil(t, err)
+	waitTransProcessed(gid)
+	r, err := dtmgrpc.QueryTrans(dtmutil.DefaultGrpcServer, gid)
+	assert.Nil(t, err)
+	assert.Equal(t, StatusSucceed, r.Transaction.Status)
+	assert.NotNil(t, r.Transaction.FinishTime)
+	assert.Equal(t, 2, len(r.Branches))
+
+	_, err = dtmgrpc.QueryTrans(dtmutil.DefaultGrpcServer, "")
+	assert.Error(t, err)
+
+	r, err = dtmgrpc.QueryTrans(dtmutil.DefaultGrpcServer, "1")
+	assert.Nil(t, err)
+	assert.Nil(t, r.Transaction)
+	assert.Equal(t, 0, len(r.Branches))
+}
+
+func TestAPIGrpcList(t *testing.T) {
+	for i := 0; i < 3; i++ { // add three
+		gid := dtmimp.GetFuncName() + fmt.Sprintf("%d", i)
+		err := genMsg(gid).Submit()
+		assert.Nil(t, err)
+		waitTransProcessed(gid)
+	}
+	r, err := dtmgrpc.ListTrans(dtmutil.DefaultGrpcServer, "", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(r.Transactions))
+	assert.NotEqual(t, "", r.NextPosition)
+
+	r2, err := dtmgrpc.ListTrans(dtmutil.DefaultGrpcServer, r.NextPosition, 1)
+	assert.Nil(t, err)
+	assert.NotEqual(t, "", r2.NextPosition)
+	assert.NotEqual(t, r.NextPosition, r2.NextPosition)
+
+	r3, err := dtmgrpc.ListTrans(dtmutil.DefaultGrpcServer, r.NextPosition, 1000)
+	assert.Nil(t, err)
+	assert.Equal(t, "", r3.NextPosition)
+}
+
+func TestAPIGrpcResetCronTime(t *testing.T) {
+	testStoreResetCronTime(t, func(timeout int64, limit int64) (int64, bool, error) {
+		r, err := dtmgrpc.ResetCronTime(dtmutil.DefaultGrpcServer, timeout, limit)
+		if err != nil {
+			return 0, false, err
+		}
+		return r.SucceedCount, r.HasRemaining, nil
+	})
+}
+
+func TestAPIGrpcForceStop(t *testing.T) {
+	saga := genSaga(dtmimp.GetFuncName(), false, false)
+	busi.MainSwitch.TransOutResult.SetOnce(dtmcli.ResultOngoing)
+	saga.Submit()
+	waitTransProcessed(saga.Gid)
+	assert.Equal(t, StatusSubmitted, getTransStatus(saga.Gid))
+
+	err := dtmgrpc.ForceStop(dtmutil.DefaultGrpcServer, saga.Gid)
+	assert.Nil(t, err)
+	assert.Equal(t, StatusFailed, getTransStatus(saga.Gid))
+
+	err = dtmgrpc.ForceStop(dtmutil.DefaultGrpcServer, saga.Gid)
+	assert.Equal(t, dtmcli.ErrFailure, err)
+}
+
+func TestAPIGrpcRetry(t *testing.T) {
+	saga := genSaga(dtmimp.GetFuncName(), false, false)
+	busi.MainSwitch.TransOutResult.SetOnce(dtmcli.ResultOngoing)
+	saga.Submit()
+	waitTransProcessed(saga.Gid)
+	assert.Equal(t, StatusSubmitted, getTransStatus(saga.Gid))
+
+	err := dtmgrpc.RetryTrans(dtmutil.DefaultGrpcServer, saga.Gid)
+	assert.Nil(t, err)
+	cronTransOnce(t, saga.Gid)
+	assert.Equal(t, StatusSucceed, getTransStatus(saga.Gid))
+
+	err = dtmgrpc.RetryTrans(dtmutil.DefaultGrpcServer, saga.Gid)
+	assert.Equal(t, dtmcli.ErrFailure, err)
+}
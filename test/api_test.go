@@ -84,7 +84,7 @@ func TestDtmMetrics(t *testing.T) {
 }
 
 func TestAPIResetCronTime(t *testing.T) {
-	testStoreResetCronTime(t, dtmimp.GetFuncName(), func(timeout int64, limit int64) (int64, bool, error) {
+	testStoreResetCronTime(t, func(timeout int64, limit int64) (int64, bool, error) {
 		sTimeout := strconv.FormatInt(timeout, 10)
 		sLimit := strconv.FormatInt(limit, 10)
 
@@ -131,3 +131,25 @@ func TestAPIForceStoppedAbnormal(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, resp.StatusCode(), http.StatusConflict)
 }
+
+func TestAPIRetry(t *testing.T) {
+	saga := genSaga(dtmimp.GetFuncName(), false, false)
+	busi.MainSwitch.TransOutResult.SetOnce("ONGOING")
+	saga.Submit()
+	waitTransProcessed(saga.Gid)
+	assert.Equal(t, StatusSubmitted, getTransStatus(saga.Gid))
+
+	resp, err := dtmimp.RestyClient.R().SetBody(map[string]string{
+		"gid": saga.Gid,
+	}).Post(dtmutil.DefaultHTTPServer + "/retry")
+	assert.Nil(t, err)
+	assert.Equal(t, resp.StatusCode(), http.StatusOK)
+	cronTransOnce(t, saga.Gid)
+	assert.Equal(t, StatusSucceed, getTransStatus(saga.Gid))
+
+	resp, err = dtmimp.RestyClient.R().SetBody(map[string]string{
+		"gid": saga.Gid,
+	}).Post(dtmutil.DefaultHTTPServer + "/retry")
+	assert.Nil(t, err)
+	assert.Equal(t, resp.StatusCode(), http.StatusConflict)
+}
@@ -0,0 +1,91 @@
+package test
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dtm-labs/dtm/dtmcli/dtmimp"
+	"github.com/dtm-labs/dtm/dtmsvr/storage"
+	"github.com/dtm-labs/dtm/dtmsvr/storage/sql"
+	"github.com/dtm-labs/dtm/dtmutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// initArchivedTrans creates a trans finished in 2000, so that only it will be archived by the test
+func initArchivedTrans(gid string) *sql.Store {
+	g, s := initTransGlobal(gid)
+	s.ChangeGlobalStatus(g, "succeed", []string{"status", "update_time"}, true)
+	dtmutil.DbGet(conf.Store.GetDBConf()).Must().Model(&storage.TransGlobalStore{}).
+		Where("gid=?", gid).Update("update_time", time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC))
+	return storage.AsStoreV2(s).(*sql.Store)
+}
+
+func TestArchiveToTable(t *testing.T) {
+	if !conf.Store.IsDB() {
+		return
+	}
+	old := conf.Store.Archive
+	defer func() { conf.Store.Archive = old }()
+	conf.Store.Archive.Target = "table"
+	gid := dtmimp.GetFuncName()
+	s := initArchivedTrans(gid)
+
+	archived, err := s.ArchiveFinished(time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC), 100)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), archived)
+	_, err = s.FindTransGlobalStoreContext(context.Background(), gid)
+	assert.Equal(t, storage.ErrNotFound, err)
+	branches, err := s.FindBranchesContext(context.Background(), gid)
+	assert.Nil(t, err)
+	assert.Empty(t, branches)
+
+	db := dtmutil.DbGet(conf.Store.GetDBConf())
+	var count int64
+	db.Must().Table(conf.Store.Archive.TransGlobalTable).Where("gid=?", gid).Count(&count)
+	assert.Equal(t, int64(1), count)
+	db.Must().Table(conf.Store.Archive.TransBranchOpTable).Where("gid=?", gid).Count(&count)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestArchiveToFile(t *testing.T) {
+	if !conf.Store.IsDB() {
+		return
+	}
+	old := conf.Store.Archive
+	defer func() { conf.Store.Archive = old }()
+	conf.Store.Archive.Target = "file"
+	conf.Store.Archive.Dir = t.TempDir()
+	gid := dtmimp.GetFuncName()
+	s := initArchivedTrans(gid)
+
+	archived, err := s.ArchiveFinished(time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC), 100)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), archived)
+	_, err = s.FindTransGlobalStoreContext(context.Background(), gid)
+	assert.Equal(t, storage.ErrNotFound, err)
+
+	files, err := filepath.Glob(filepath.Join(conf.Store.Archive.Dir, "*.jsonl.gz"))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(files))
+	f, err := os.Open(files[0])
+	assert.Nil(t, err)
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	assert.Nil(t, err)
+	line := struct {
+		Global   storage.TransGlobalStore
+		Branches []storage.TransBranchStore
+	}{}
+	assert.Nil(t, json.NewDecoder(zr).Decode(&line))
+	assert.Equal(t, gid, line.Global.Gid)
+	assert.Equal(t, 1, len(line.Branches))
+
+	archived, err = s.ArchiveFinished(time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC), 100)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), archived)
+}
@@ -0,0 +1,74 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dtm-labs/dtm/dtmcli"
+	"github.com/dtm-labs/dtm/dtmcli/dtmimp"
+	"github.com/dtm-labs/dtm/test/busi"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func barrierCount(gid string) int {
+	var count int
+	sql := fmt.Sprintf("select count(1) from %s where gid=?", dtmimp.BarrierTableName)
+	err := dbGet().ToSQLDB().QueryRow(sql, gid).Scan(&count)
+	e2p(err)
+	return count
+}
+
+func TestBarrierCleaner(t *testing.T) {
+	gid := dtmimp.GetFuncName()
+	db := dbGet().ToSQLDB()
+	_, err := dtmimp.InsertBarrier(db, "saga", gid, "01", "action", "01", "action")
+	assert.Nil(t, err)
+	_, err = dtmimp.InsertBarrier(db, "saga", gid, "02", "action", "01", "action")
+	assert.Nil(t, err)
+	_, err = dtmimp.DBExec(db, fmt.Sprintf("update %s set create_time='2000-01-01' where gid=? and branch_id='01'", dtmimp.BarrierTableName), gid)
+	assert.Nil(t, err)
+
+	cleaner := dtmcli.NewBarrierCleaner(db)
+	cleaner.Retention = time.Minute
+	_, err = cleaner.CleanOnce()
+	assert.Error(t, err)
+
+	cleaner.Retention = 24 * time.Hour
+	cleaner.BatchSize = 1000
+	for n := cleaner.BatchSize; n == cleaner.BatchSize; {
+		n, err = cleaner.CleanOnce()
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, 1, barrierCount(gid))
+}
+
+func TestBarrierCleanerMongo(t *testing.T) {
+	gid := dtmimp.GetFuncName()
+	fs := strings.Split(dtmimp.BarrierTableName, ".")
+	barrier := busi.MongoGet().Database(fs[0]).Collection(fs[1])
+	_, err := barrier.InsertOne(context.Background(), bson.D{
+		{Key: "_id", Value: primitive.NewObjectIDFromTimestamp(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC))},
+		{Key: "gid", Value: gid}, {Key: "branch_id", Value: "01"}, {Key: "op", Value: "action"}, {Key: "barrier_id", Value: "01"},
+	})
+	assert.Nil(t, err)
+	_, err = barrier.InsertOne(context.Background(), bson.D{
+		{Key: "gid", Value: gid}, {Key: "branch_id", Value: "02"}, {Key: "op", Value: "action"}, {Key: "barrier_id", Value: "01"},
+	})
+	assert.Nil(t, err)
+
+	cleaner := dtmcli.NewMongoBarrierCleaner(busi.MongoGet())
+	cleaner.Retention = 24 * time.Hour
+	cleaner.BatchSize = 1000
+	for n := cleaner.BatchSize; n == cleaner.BatchSize; {
+		n, err = cleaner.CleanOnce()
+		assert.Nil(t, err)
+	}
+	count, err := barrier.CountDocuments(context.Background(), bson.M{"gid": gid})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+}
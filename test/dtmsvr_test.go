@@ -12,7 +12,6 @@ import (
 
 	"github.com/dtm-labs/dtm/dtmcli/dtmimp"
 	"github.com/dtm-labs/dtm/dtmsvr"
-	"github.com/dtm-labs/dtm/dtmsvr/config"
 	"github.com/dtm-labs/dtm/dtmutil"
 	"github.com/dtm-labs/dtm/test/busi"
 	"github.com/stretchr/testify/assert"
@@ -36,9 +35,6 @@ func getBranchesStatus(gid string) []string {
 }
 
 func TestUpdateBranchAsync(t *testing.T) {
-	if conf.Store.Driver != config.Mysql {
-		return
-	}
 	conf.UpdateBranchSync = 0
 	saga := genSaga1(dtmimp.GetFuncName(), false, false)
 	saga.WaitResult = true
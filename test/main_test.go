@@ -43,6 +43,20 @@ func TestMain(m *testing.M) {
 	tenv := os.Getenv("TEST_STORE")
 	if tenv == "boltdb" {
 		conf.Store.Driver = "boltdb"
+	} else if tenv == "mongo" {
+		conf.Store.Driver = "mongo"
+		conf.Store.Host = "localhost"
+		conf.Store.Port = 27017
+		conf.Store.User = ""
+		conf.Store.Password = ""
+	} else if tenv == "etcd" {
+		conf.Store.Driver = "etcd"
+		conf.Store.Endpoints = "localhost:2379"
+		conf.Store.User = ""
+		conf.Store.Password = ""
+	} else if tenv == "sqlite" {
+		conf.Store.Driver = "sqlite"
+		conf.Store.Host = os.TempDir() + "/dtm-sqlite"
 	} else if tenv == "mysql" {
 		conf.Store.Driver = "mysql"
 		conf.Store.Host = "localhost"
@@ -0,0 +1,57 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dtm-labs/dtm/dtmcli"
+	"github.com/dtm-labs/dtm/dtmcli/dtmimp"
+	"github.com/dtm-labs/dtm/dtmgrpc"
+	"github.com/dtm-labs/dtm/dtmutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMsgBatchPrepare(t *testing.T) {
+	gid := dtmimp.GetFuncName()
+	msg1 := genMsg(gid + "-1")
+	msg2 := genMsg(gid + "-2")
+	errs, err := dtmcli.PrepareMsgBatch(dtmutil.DefaultHTTPServer, []*dtmcli.Msg{msg1, msg2}, "")
+	assert.Nil(t, err)
+	assert.Equal(t, []error{nil, nil}, errs)
+	assert.Equal(t, StatusPrepared, getTransStatus(msg1.Gid))
+	assert.Equal(t, StatusPrepared, getTransStatus(msg2.Gid))
+
+	for _, msg := range []*dtmcli.Msg{msg1, msg2} {
+		err = msg.Submit()
+		assert.Nil(t, err)
+		waitTransProcessed(msg.Gid)
+		assert.Equal(t, StatusSucceed, getTransStatus(msg.Gid))
+	}
+}
+
+func TestMsgBatchSubmit(t *testing.T) {
+	msg := genMsg(dtmimp.GetFuncName())
+	errs, err := dtmcli.SubmitMsgBatch(dtmutil.DefaultHTTPServer, []*dtmcli.Msg{msg})
+	assert.Nil(t, err)
+	assert.Equal(t, []error{nil}, errs)
+	waitTransProcessed(msg.Gid)
+	assert.Equal(t, []string{StatusSucceed, StatusSucceed}, getBranchesStatus(msg.Gid))
+	assert.Equal(t, StatusSucceed, getTransStatus(msg.Gid))
+
+	errs, err = dtmcli.SubmitMsgBatch(dtmutil.DefaultHTTPServer, []*dtmcli.Msg{msg})
+	assert.Nil(t, err)
+	assert.True(t, errors.Is(errs[0], dtmcli.ErrFailure))
+}
+
+func TestMsgBatchGrpcSubmit(t *testing.T) {
+	msg := genGrpcMsg(dtmimp.GetFuncName())
+	errs, err := dtmgrpc.SubmitMsgGrpcBatch(dtmutil.DefaultGrpcServer, []*dtmgrpc.MsgGrpc{msg})
+	assert.Nil(t, err)
+	assert.Equal(t, []error{nil}, errs)
+	waitTransProcessed(msg.Gid)
+	assert.Equal(t, StatusSucceed, getTransStatus(msg.Gid))
+
+	errs, err = dtmgrpc.PrepareMsgGrpcBatch(dtmutil.DefaultGrpcServer, []*dtmgrpc.MsgGrpc{msg}, "")
+	assert.Nil(t, err)
+	assert.True(t, errors.Is(errs[0], dtmcli.ErrFailure))
+}
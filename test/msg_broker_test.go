@@ -0,0 +1,88 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dtm-labs/dtm/dtmcli"
+	"github.com/dtm-labs/dtm/dtmcli/dtmimp"
+	"github.com/dtm-labs/dtm/dtmsvr/broker"
+	"github.com/dtm-labs/dtm/dtmutil"
+	"github.com/dtm-labs/dtm/test/busi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMsgBrokerNormal(t *testing.T) {
+	p := broker.NewMemoryProducer() // stands in for a kafka producer registered by a package linked into dtm
+	broker.Register("kafka", p)
+	defer broker.Unregister("kafka")
+
+	gid := dtmimp.GetFuncName()
+	req := busi.GenTransReq(30, false, false)
+	msg := dtmcli.NewMsg(dtmutil.DefaultHTTPServer, gid).
+		Add(busi.Busi+"/TransOut", &req).
+		Add("kafka://trans_in", &req)
+	err := msg.Submit()
+	assert.Nil(t, err)
+	waitTransProcessed(gid)
+	assert.Equal(t, []string{StatusSucceed, StatusSucceed}, getBranchesStatus(gid))
+	assert.Equal(t, StatusSucceed, getTransStatus(gid))
+
+	msgs := p.Messages("trans_in")
+	assert.Equal(t, 1, len(msgs))
+	assert.Equal(t, gid, msgs[0].Gid)
+	assert.Equal(t, "02", msgs[0].BranchID)
+	assert.Equal(t, dtmimp.MustMarshalString(&req), string(msgs[0].Payload))
+}
+
+func TestMsgBrokerRetry(t *testing.T) {
+	p := broker.NewMemoryProducer()
+	p.Err = errors.New("broker unavailable")
+	broker.Register("nats", p)
+	defer broker.Unregister("nats")
+
+	gid := dtmimp.GetFuncName()
+	req := busi.GenTransReq(30, false, false)
+	msg := dtmcli.NewMsg(dtmutil.DefaultHTTPServer, gid).
+		Add("nats://trans_in", &req)
+	err := msg.Submit()
+	assert.Nil(t, err)
+	waitTransProcessed(gid)
+	assert.Equal(t, []string{StatusPrepared}, getBranchesStatus(gid))
+	assert.Equal(t, StatusSubmitted, getTransStatus(gid))
+
+	p.Err = nil
+	cronTransOnce(t, gid)
+	assert.Equal(t, []string{StatusSucceed}, getBranchesStatus(gid))
+	assert.Equal(t, StatusSucceed, getTransStatus(gid))
+	assert.Equal(t, 1, len(p.Messages("trans_in")))
+}
+
+func TestMsgBrokerRedisStream(t *testing.T) {
+	rd := busi.RedisGet()
+	broker.Register("redis-stream", broker.NewRedisStreamProducer(rd, 1000, time.Minute))
+	defer broker.Unregister("redis-stream")
+
+	gid := dtmimp.GetFuncName()
+	stream := "dtm_test_stream_" + gid
+	req := busi.GenTransReq(30, false, false)
+	msg := dtmcli.NewMsg(dtmutil.DefaultHTTPServer, gid).
+		Add("redis-stream://"+stream, &req)
+	err := msg.Submit()
+	assert.Nil(t, err)
+	waitTransProcessed(gid)
+	assert.Equal(t, StatusSucceed, getTransStatus(gid))
+
+	// publish again will be deduplicated
+	p, target := broker.Lookup("redis-stream://" + stream)
+	err = p.Publish(target, &broker.Message{Gid: gid, TransType: "msg", BranchID: "01", Op: dtmimp.OpAction})
+	assert.Nil(t, err)
+
+	entries, err := rd.XRange(context.Background(), stream, "-", "+").Result()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, gid, entries[0].Values["gid"])
+	assert.Equal(t, dtmimp.MustMarshalString(&req), entries[0].Values["payload"])
+}
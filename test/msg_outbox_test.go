@@ -0,0 +1,136 @@
+package test
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/dtm-labs/dtm/dtmcli"
+	"github.com/dtm-labs/dtm/dtmcli/dtmimp"
+	"github.com/dtm-labs/dtm/dtmgrpc"
+	"github.com/dtm-labs/dtm/test/busi"
+	"github.com/stretchr/testify/assert"
+)
+
+func outboxCount(gid string) int {
+	var count int
+	sql := fmt.Sprintf("select count(1) from %s where gid=?", dtmimp.OutboxTableName)
+	err := dbGet().ToSQLDB().QueryRow(sql, gid).Scan(&count)
+	e2p(err)
+	return count
+}
+
+func TestMsgOutboxNormal(t *testing.T) {
+	before := getBeforeBalances("mysql")
+	gid := dtmimp.GetFuncName()
+	req := busi.GenTransReq(30, false, false)
+	msg := dtmcli.NewMsg(DtmServer, gid).
+		Add(busi.Busi+"/SagaBTransIn", req)
+	err := msg.DoAndSaveOutbox(dbGet().ToSQLDB(), func(tx *sql.Tx) error {
+		return busi.SagaAdjustBalance(tx, busi.TransOutUID, -req.Amount, "SUCCESS")
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, outboxCount(gid))
+
+	relay := dtmcli.NewOutboxRelay(dbGet().ToSQLDB())
+	n, err := relay.RelayOnce()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+	waitTransProcessed(gid)
+	assert.Equal(t, 0, outboxCount(gid))
+	assert.Equal(t, []string{StatusSucceed}, getBranchesStatus(gid))
+	assert.Equal(t, StatusSucceed, getTransStatus(gid))
+	assertNotSameBalance(t, before, "mysql")
+}
+
+func TestMsgOutboxBusiFailed(t *testing.T) {
+	before := getBeforeBalances("mysql")
+	gid := dtmimp.GetFuncName()
+	req := busi.GenTransReq(30, false, false)
+	msg := dtmcli.NewMsg(DtmServer, gid).
+		Add(busi.Busi+"/SagaBTransIn", req)
+	err := msg.DoAndSaveOutbox(dbGet().ToSQLDB(), func(tx *sql.Tx) error {
+		return errors.New("an error")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 0, outboxCount(gid))
+	assertSameBalance(t, before, "mysql")
+}
+
+func TestMsgOutboxDuplicated(t *testing.T) {
+	gid := dtmimp.GetFuncName()
+	req := busi.GenTransReq(30, false, false)
+	msg := dtmcli.NewMsg(DtmServer, gid).
+		Add(busi.Busi+"/SagaBTransIn", req)
+	busiCall := func(tx *sql.Tx) error {
+		return busi.SagaAdjustBalance(tx, busi.TransOutUID, -req.Amount, "SUCCESS")
+	}
+	err := msg.DoAndSaveOutbox(dbGet().ToSQLDB(), busiCall)
+	assert.Nil(t, err)
+	err = msg.DoAndSaveOutbox(dbGet().ToSQLDB(), busiCall)
+	assert.Equal(t, dtmcli.ErrDuplicated, err)
+	assert.Equal(t, 1, outboxCount(gid))
+
+	_, err = dtmcli.NewOutboxRelay(dbGet().ToSQLDB()).RelayOnce()
+	assert.Nil(t, err)
+	waitTransProcessed(gid)
+	assert.Equal(t, StatusSucceed, getTransStatus(gid))
+}
+
+func TestMsgOutboxGrpc(t *testing.T) {
+	gid := dtmimp.GetFuncName()
+	req := busi.GenBusiReq(30, false, false)
+	msg := dtmgrpc.NewMsgGrpc(DtmGrpcServer, gid).
+		Add(busi.BusiGrpc+"/busi.Busi/TransIn", req)
+	err := msg.DoAndSaveOutbox(dbGet().ToSQLDB(), func(tx *sql.Tx) error {
+		return busi.SagaAdjustBalance(tx, busi.TransOutUID, -int(req.Amount), "SUCCESS")
+	})
+	assert.Nil(t, err)
+
+	_, err = dtmcli.NewOutboxRelay(dbGet().ToSQLDB()).RelayOnce()
+	assert.Nil(t, err)
+	waitTransProcessed(gid)
+	assert.Equal(t, []string{StatusSucceed}, getBranchesStatus(gid))
+	assert.Equal(t, StatusSucceed, getTransStatus(gid))
+}
+
+func TestMsgOutboxMalformed(t *testing.T) {
+	gid := dtmimp.GetFuncName()
+	_, err := dtmimp.InsertOutbox(dbGet().ToSQLDB(), gid+"-bad", "http", DtmServer, "malformed")
+	assert.Nil(t, err)
+	_, err = dtmimp.InsertOutbox(dbGet().ToSQLDB(), gid+"-bad", "unknown", DtmServer, "{}")
+	assert.Nil(t, err)
+	req := busi.GenTransReq(30, false, false)
+	msg := dtmcli.NewMsg(DtmServer, gid).
+		Add(busi.Busi+"/SagaBTransIn", req)
+	err = msg.DoAndSaveOutbox(dbGet().ToSQLDB(), func(tx *sql.Tx) error {
+		return busi.SagaAdjustBalance(tx, busi.TransOutUID, -req.Amount, "SUCCESS")
+	})
+	assert.Nil(t, err)
+
+	// the bad rows fill a whole batch. they are kept, and not selected again, so the row after them is relayed
+	relay := dtmcli.NewOutboxRelay(dbGet().ToSQLDB())
+	relay.BatchSize = 2
+	n, err := relay.RelayOnce()
+	assert.Error(t, err)
+	assert.Equal(t, 0, n)
+	n, err = relay.RelayOnce()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+	waitTransProcessed(gid)
+	assert.Equal(t, 0, outboxCount(gid))
+	assert.Equal(t, 2, outboxCount(gid+"-bad"))
+	_, err = dbGet().ToSQLDB().Exec(fmt.Sprintf("delete from %s where gid=?", dtmimp.OutboxTableName), gid+"-bad")
+	assert.Nil(t, err)
+}
+
+func TestMsgOutboxRelayStop(t *testing.T) {
+	relay := dtmcli.NewOutboxRelay(dbGet().ToSQLDB())
+	relay.Stop() // stop before start
+	relay.Stop()
+	relay = dtmcli.NewOutboxRelay(dbGet().ToSQLDB())
+	relay.Start()
+	relay.Stop()
+	relay.Stop()
+}
@@ -72,6 +72,20 @@ func TestSagaAbnormal(t *testing.T) {
 	assert.Error(t, err) // a succeed trans can't accept submit
 }
 
+func TestSagaSubmitDifferentBody(t *testing.T) {
+	saga := genSaga(dtmimp.GetFuncName(), false, false)
+	busi.MainSwitch.TransOutResult.SetOnce("ONGOING")
+	err := saga.Submit()
+	assert.Nil(t, err)
+	waitTransProcessed(saga.Gid)
+	err = genSaga1(saga.Gid, false, false).Submit() // same gid with different steps
+	assert.ErrorIs(t, err, dtmcli.ErrFailure)
+	err = saga.Submit() // same body, ignored
+	assert.Nil(t, err)
+	cronTransOnce(t, saga.Gid)
+	assert.Equal(t, StatusSucceed, getTransStatus(saga.Gid))
+}
+
 func TestSagaEmptyUrl(t *testing.T) {
 	saga := dtmcli.NewSaga(dtmutil.DefaultHTTPServer, dtmimp.GetFuncName())
 	req := busi.GenTransReq(30, false, false)
@@ -0,0 +1,55 @@
+package test
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/dtm-labs/dtm/dtmcli/dtmimp"
+	"github.com/dtm-labs/dtm/dtmgrpc/dtmgimp"
+	"github.com/dtm-labs/dtm/dtmgrpc/dtmgpb"
+	"github.com/dtm-labs/dtm/dtmutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchGrpc(t *testing.T) {
+	msg := genGrpcMsg(dtmimp.GetFuncName())
+	err := msg.Prepare("")
+	assert.Nil(t, err)
+
+	stream, err := dtmgimp.MustGetDtmClient(dtmutil.DefaultGrpcServer).Watch(context.Background(), &dtmgpb.DtmWatchRequest{Gid: msg.Gid})
+	assert.Nil(t, err)
+	e, err := stream.Recv()
+	assert.Nil(t, err)
+	assert.Equal(t, StatusPrepared, e.Status)
+
+	err = msg.Submit()
+	assert.Nil(t, err)
+	waitTransProcessed(msg.Gid)
+	statuses := []string{}
+	for {
+		e, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(t, err)
+		statuses = append(statuses, e.BranchID+":"+e.Status)
+	}
+	assert.Equal(t, []string{":" + StatusSubmitted, "01:" + StatusSucceed, "02:" + StatusSucceed, ":" + StatusSucceed}, statuses)
+}
+
+func TestWatchSSE(t *testing.T) {
+	msg := genMsg(dtmimp.GetFuncName())
+	msg.Submit()
+	waitTransProcessed(msg.Gid)
+
+	resp, err := http.Get(dtmutil.DefaultHTTPServer + "/watch?gid=" + msg.Gid)
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.Nil(t, err)
+	assert.Contains(t, string(body), "event:status")
+	assert.Contains(t, string(body), `"status":"succeed"`)
+}